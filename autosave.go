@@ -0,0 +1,40 @@
+package main
+
+import "time"
+
+func (e *Editor) AutoSave() {
+	if !e.mu.TryLock() {
+		return
+	}
+	defer e.mu.Unlock()
+
+	interval := time.Duration(e.Config.AutoSaveSeconds) * time.Second
+	if e.prompting || interval <= 0 || time.Since(e.lastAutoSave) < interval {
+		return
+	}
+	e.lastAutoSave = time.Now()
+
+	saved := 0
+	current := e.Buffer
+	for _, buf := range e.Buffers {
+		e.Buffer = buf
+		if e.Dirty == 0 || e.Filename == "" || e.ReadOnly || e.ChangedOnDisk() {
+			continue
+		}
+
+		if _, err := e.WriteFile(e.Filename); err != nil {
+			Debugf("auto-save %s failed: %v", e.Filename, err)
+			continue
+		}
+		e.RecordDiskState()
+		e.RefreshGitSigns()
+		e.RefreshGitBranch()
+		saved++
+	}
+	e.Buffer = current
+
+	if saved > 0 {
+		e.SetStatusMessage("auto-saved")
+		e.Render()
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "bytes"
+
+const binarySniffLen = 8000
+
+func looksBinary(head []byte) bool {
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+
+	control := 0
+	for _, c := range head {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' && c != '\v' && c != 0x1b {
+			control++
+		}
+	}
+	return len(head) > 0 && control*10 > len(head)
+}
+
+func (e *Editor) MarkBinary() {
+	e.Binary = true
+	if e.ForceBinary {
+		e.SetStatusMessage("\x1b[33;1mWARNING\x1b[0m %s looks like a binary file; saving may corrupt it", e.DisplayName())
+		return
+	}
+
+	e.ReadOnly = true
+	e.SetStatusMessage("%s looks like a binary file; opened read-only (run with --force to edit it)", e.DisplayName())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openTestFile(t *testing.T, name, content string) *Editor {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	filename := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEditor()
+	if err := e.OpenFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		head string
+		want bool
+	}{
+		{"", false},
+		{"plain text\n", false},
+		{"tabs\tand\r\nCRLF\n", false},
+		{"\x1b[31mcolored\x1b[m\n", false},
+		{"nul\x00byte", true},
+		{"\x01\x02\x03\x04 mostly control", true},
+	}
+
+	for _, tt := range tests {
+		if got := looksBinary([]byte(tt.head)); got != tt.want {
+			t.Errorf("looksBinary(%q) = %v, want %v", tt.head, got, tt.want)
+		}
+	}
+}
+
+func TestBinarySniffPastDefaultBuffer(t *testing.T) {
+	e := openTestFile(t, "late-nul.bin", strings.Repeat("a", 6000)+"\x00tail\n")
+	if !e.Binary || !e.ReadOnly {
+		t.Fatalf("NUL at byte 6000 not detected: Binary=%v ReadOnly=%v", e.Binary, e.ReadOnly)
+	}
+
+	e = openTestFile(t, "text.txt", strings.Repeat("text line\n", 1000))
+	if e.Binary || e.ReadOnly {
+		t.Fatalf("text file flagged as binary: Binary=%v ReadOnly=%v", e.Binary, e.ReadOnly)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import "sort"
+
+func (e *Editor) ToggleBookmark() {
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	if e.bookmarks[e.CY] {
+		delete(e.bookmarks, e.CY)
+		e.SetStatusMessage("Removed bookmark from line %d", e.CY+1)
+		return
+	}
+	if e.bookmarks == nil {
+		e.bookmarks = make(map[int]bool)
+	}
+	e.bookmarks[e.CY] = true
+	e.SetStatusMessage("Bookmarked line %d", e.CY+1)
+}
+
+func (e *Editor) NextBookmark(dir int) {
+	if len(e.bookmarks) == 0 {
+		e.SetStatusMessage("No bookmarks")
+		return
+	}
+
+	lines := make([]int, 0, len(e.bookmarks))
+	for y := range e.bookmarks {
+		lines = append(lines, y)
+	}
+	sort.Ints(lines)
+
+	i := sort.SearchInts(lines, e.CY)
+	if dir > 0 {
+		if i < len(lines) && lines[i] == e.CY {
+			i++
+		}
+		e.CY = lines[i%len(lines)]
+	} else {
+		e.CY = lines[(i-1+len(lines))%len(lines)]
+	}
+	e.CX = 0
+	e.Scroll()
+}
+
+func (e *Editor) shiftBookmarks(at, delta int) {
+	if len(e.bookmarks) == 0 {
+		return
+	}
+
+	shifted := make(map[int]bool, len(e.bookmarks))
+	for y := range e.bookmarks {
+		if delta < 0 && y == at {
+			continue
+		}
+		if y > at || (delta > 0 && y == at) {
+			y += delta
+		}
+		shifted[y] = true
+	}
+	e.bookmarks = shifted
+}
@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestBookmarksFollowLineEdits(t *testing.T) {
+	e := newTestEditor(numberedLines(10)...)
+	for _, y := range []int{2, 5, 8} {
+		e.CY = y
+		e.ToggleBookmark()
+	}
+
+	e.InsertRow(0, "new first line")
+	e.DeleteRow(6)
+	e.MoveRow(9, -1)
+	e.MoveRow(3, 1)
+
+	want := map[int]bool{4: true, 9: true}
+	if len(e.bookmarks) != len(want) {
+		t.Fatalf("bookmarks = %v, want %v", e.bookmarks, want)
+	}
+	for y := range want {
+		if !e.bookmarks[y] {
+			t.Fatalf("bookmarks = %v, want %v", e.bookmarks, want)
+		}
+	}
+}
+
+func TestNextBookmarkWraps(t *testing.T) {
+	e := newTestEditor(numberedLines(10)...)
+	for _, y := range []int{2, 7} {
+		e.CY = y
+		e.ToggleBookmark()
+	}
+
+	e.CY = 4
+	var got []int
+	for i := 0; i < 3; i++ {
+		e.NextBookmark(1)
+		got = append(got, e.CY)
+	}
+	for i := 0; i < 3; i++ {
+		e.NextBookmark(-1)
+		got = append(got, e.CY)
+	}
+
+	want := []int{7, 2, 7, 2, 7, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("visited %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBookmarksInLargeFile(t *testing.T) {
+	e := openLargeTestFile(t, 80000)
+	e.CY = 10
+	e.Scroll()
+	e.ToggleBookmark()
+
+	e.CY = 70000
+	e.Scroll()
+	if e.Rows[10] != nil {
+		t.Fatal("line 11 is still loaded")
+	}
+
+	e.NextBookmark(1)
+	if e.CY != 10 {
+		t.Fatalf("next bookmark went to line %d, want 11", e.CY+1)
+	}
+	if _, ok := (bookmarkSigns{}).Sign(e, e.LoadRow(10)); !ok {
+		t.Fatal("bookmark sign missing after the row was reloaded")
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+var ErrIsDirectory = errors.New("is a directory")
+
+type fileBrowser struct {
+	dir     string
+	entries []string
+	sel     int
+	offset  int
+}
+
+func readBrowserEntries(dir string) ([]string, error) {
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs, files []string
+	for _, entry := range list {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			dirs = append(dirs, name+"/")
+		} else {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+
+	entries := dirs
+	if filepath.Dir(dir) != dir {
+		entries = append([]string{"../"}, dirs...)
+	}
+	return append(entries, files...), nil
+}
+
+func (e *Editor) BrowseFiles() error {
+	dir := "."
+	if e.Filename != "" {
+		dir = filepath.Dir(e.Filename)
+	}
+
+	filename, err := e.Browse(dir)
+	if err != nil {
+		if err == ErrPromptCanceled {
+			return nil
+		}
+		return err
+	}
+	return e.OpenInNewBuffer(filename)
+}
+
+func (e *Editor) Browse(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	entries, err := readBrowserEntries(dir)
+	if err != nil {
+		return "", err
+	}
+
+	e.browser = &fileBrowser{dir: dir, entries: entries}
+	defer func() {
+		e.browser = nil
+		e.InvalidateScreen()
+	}()
+	e.SetStatusMessage("%s (Enter = Open | Backspace = Up | ESC = Cancel)", dir)
+
+	for {
+		e.scrollBrowser()
+		e.Render()
+
+		k, err := e.WaitKey()
+		if err != nil {
+			return "", err
+		}
+
+		fb := e.browser
+		e.SetStatusMessage("%s (Enter = Open | Backspace = Up | ESC = Cancel)", fb.dir)
+		switch k {
+		case keyArrowUp:
+			fb.sel--
+		case keyArrowDown:
+			fb.sel++
+		case keyPageUp:
+			fb.sel -= e.TextRows()
+		case keyPageDown:
+			fb.sel += e.TextRows()
+		case keyHome, keyCtrlHome:
+			fb.sel = 0
+		case keyEnd, keyCtrlEnd:
+			fb.sel = len(fb.entries) - 1
+		case keyBackspace, key(ctrl('h')), keyArrowLeft:
+			e.enterBrowserDir(filepath.Dir(fb.dir))
+		case keyEnter, keyArrowRight:
+			if len(fb.entries) == 0 {
+				break
+			}
+			name := fb.entries[fb.sel]
+			path := filepath.Join(fb.dir, name)
+			if strings.HasSuffix(name, "/") {
+				e.enterBrowserDir(path)
+				break
+			}
+			if k == keyArrowRight {
+				break
+			}
+			e.SetStatusMessage("")
+			if wd, err := os.Getwd(); err == nil {
+				if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+					path = rel
+				}
+			}
+			return path, nil
+		case key('\x1b'):
+			e.SetStatusMessage("")
+			return "", ErrPromptCanceled
+		}
+
+		if fb = e.browser; fb.sel >= len(fb.entries) {
+			fb.sel = len(fb.entries) - 1
+		}
+		if fb.sel < 0 {
+			fb.sel = 0
+		}
+	}
+}
+
+func (e *Editor) enterBrowserDir(dir string) {
+	entries, err := readBrowserEntries(dir)
+	if err != nil {
+		e.SetStatusMessage("Can't read %s: %s", dir, err.Error())
+		return
+	}
+
+	previous := filepath.Base(e.browser.dir) + "/"
+	e.browser = &fileBrowser{dir: dir, entries: entries}
+	for i, name := range entries {
+		if name == previous {
+			e.browser.sel = i
+		}
+	}
+	e.SetStatusMessage("%s (Enter = Open | Backspace = Up | ESC = Cancel)", dir)
+}
+
+func (e *Editor) scrollBrowser() {
+	fb := e.browser
+	if fb.sel < fb.offset {
+		fb.offset = fb.sel
+	}
+	if fb.sel >= fb.offset+e.TextRows() {
+		fb.offset = fb.sel - e.TextRows() + 1
+	}
+}
+
+func (e *Editor) DrawBrowserRows(b *strings.Builder) {
+	fb := e.browser
+	cols := e.TextCols()
+	for y := 0; y < e.TextRows(); y++ {
+		i := fb.offset + y
+		if i < len(fb.entries) {
+			name := runewidth.Truncate(fb.entries[i], cols, "")
+			if i == fb.sel {
+				b.WriteString("\x1b[7m")
+			}
+			if strings.HasSuffix(name, "/") {
+				b.WriteString(e.Fg(e.Theme.Keyword1))
+			}
+			b.WriteString(runewidth.FillRight(name, cols))
+			b.WriteString("\x1b[m")
+		} else if len(fb.entries) == 0 && y == 0 {
+			b.WriteString("(empty directory)")
+		} else {
+			b.WriteString(e.Config.EmptyLineChar)
+		}
+		b.WriteString("\x1b[K\r\n")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+type Buffer struct {
+	CX, CY           int
+	RX               int
+	RowOffset        int
+	ColOffset        int
+	Rows             []*Row
+	Dirty            int
+	Filename         string
+	LineEnding       string
+	MixedLineEndings bool
+	NoFinalNewline   bool
+	HasBOM           bool
+	Syntax           *EditorSyntax
+	Selecting        bool
+	SelAnchorX       int
+	SelAnchorY       int
+	ReadOnly         bool
+	Binary           bool
+
+	pageRX    int
+	stickyCol int
+	cursors   []cursor
+	hex       *hexView
+	marks     map[rune]cursor
+	folds     int
+	bookmarks map[int]bool
+	gitSigns  int
+	gitBranch string
+
+	snippetStops  []cursor
+	snippetTop    int
+	snippetBottom int
+
+	undo undoHistory
+	disk diskState
+	lazy *lazyFile
+}
+
+func (e *Editor) NewBuffer() *Buffer {
+	b := &Buffer{ReadOnly: e.Config.ReadOnly, pageRX: -1, stickyCol: -1}
+	e.Buffers = append(e.Buffers, b)
+	e.Buffer = b
+	return b
+}
+
+func (e *Editor) BufferIndex() int {
+	for i, b := range e.Buffers {
+		if b == e.Buffer {
+			return i
+		}
+	}
+	return -1
+}
+
+func (e *Editor) DisplayName() string {
+	if e.Filename == "" {
+		return "[No Name]"
+	}
+	return e.Filename
+}
+
+func (e *Editor) SwitchBuffer(delta int) {
+	if len(e.Buffers) < 2 {
+		e.SetStatusMessage("No other buffers")
+		return
+	}
+
+	e.SelectBuffer((e.BufferIndex() + delta + len(e.Buffers)) % len(e.Buffers))
+}
+
+func (e *Editor) SelectBuffer(i int) {
+	if i < 0 || i >= len(e.Buffers) {
+		return
+	}
+
+	e.Buffer = e.Buffers[i]
+	e.SetStatusMessage("Switched to %s", e.DisplayName())
+}
+
+func (e *Editor) CloseBuffer() {
+	i := e.BufferIndex()
+	if i == -1 {
+		return
+	}
+
+	e.RemoveSwap()
+	e.CloseLargeFile()
+	e.Buffers = append(e.Buffers[:i], e.Buffers[i+1:]...)
+	if len(e.Buffers) == 0 {
+		e.NewBuffer()
+		return
+	}
+	if i >= len(e.Buffers) {
+		i = len(e.Buffers) - 1
+	}
+	e.Buffer = e.Buffers[i]
+	e.SetStatusMessage("Switched to %s", e.DisplayName())
+}
+
+// dropBuffer discards a buffer that failed to open and switches back to
+// previous. Unlike CloseBuffer it leaves the file's swap file in place.
+func (e *Editor) dropBuffer(previous *Buffer) {
+	e.CloseLargeFile()
+	if i := e.BufferIndex(); i != -1 {
+		e.Buffers = append(e.Buffers[:i], e.Buffers[i+1:]...)
+	}
+	e.Buffer = previous
+}
+
+func (e *Editor) OpenBuffer() error {
+	filename, err := e.PromptFilename("Open: %s (ESC to cancel | Tab = Complete)")
+	if err != nil {
+		return err
+	}
+
+	return e.OpenInNewBuffer(filename)
+}
+
+func (e *Editor) OpenInNewBuffer(filename string) error {
+	previous := e.Buffer
+	e.NewBuffer()
+	err := e.OpenFile(filename)
+	if errors.Is(err, ErrIsDirectory) {
+		e.dropBuffer(previous)
+		picked, err := e.Browse(filename)
+		if err != nil {
+			if err == ErrPromptCanceled {
+				return nil
+			}
+			return err
+		}
+		return e.OpenInNewBuffer(picked)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		e.dropBuffer(previous)
+		e.SetStatusMessage("Can't open %s: %s", filename, err.Error())
+		return nil
+	}
+
+	e.RestoreCursorFromHistory()
+	e.SetStatusMessage("Opened %s", e.DisplayName())
+	return nil
+}
+
+func (e *Editor) ReloadFile() error {
+	if e.Filename == "" {
+		e.SetStatusMessage("No file to reload")
+		return nil
+	}
+
+	if e.Dirty > 0 {
+		ok, err := e.Confirm("%s has unsaved changes. Discard them and reload?", e.DisplayName())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			e.SetStatusMessage("Reload aborted")
+			return nil
+		}
+	}
+
+	e.RemoveSwap()
+	cx, cy := e.CX, e.CY
+	e.Rows = nil
+	e.folds, e.bookmarks = 0, nil
+	e.RowOffset, e.ColOffset = 0, 0
+	e.ClearSelection()
+
+	if err := e.OpenFile(e.Filename); err != nil {
+		return err
+	}
+
+	e.CY = cy
+	if e.CY > len(e.Rows) {
+		e.CY = len(e.Rows)
+	}
+	e.CX = cx
+	if e.CY == len(e.Rows) {
+		e.CX = 0
+	} else if linelen := len(e.RowAt(e.CY).chars); e.CX > linelen {
+		e.CX = linelen
+	}
+
+	e.SetStatusMessage("Reloaded %s", e.DisplayName())
+	return nil
+}
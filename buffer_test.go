@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFailedOpenKeepsSwapFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	filename := filepath.Join(t.TempDir(), "long.txt")
+	if err := os.WriteFile(filename, []byte(strings.Repeat("x", 100000)+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	swap := swapPath(filename)
+	if err := os.WriteFile(swap, []byte("unsaved\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEditor("first")
+	first := e.Buffer
+	if err := e.OpenInNewBuffer(filename); err != nil {
+		t.Fatal(err)
+	}
+	if e.Buffer != first || len(e.Buffers) != 1 {
+		t.Fatalf("failed open left %d buffers", len(e.Buffers))
+	}
+	if !strings.HasPrefix(e.StatusMessage, "Can't open") {
+		t.Fatalf("status = %q", e.StatusMessage)
+	}
+	if _, err := os.Stat(swap); err != nil {
+		t.Fatalf("swap file removed: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "unicode"
+
+func upperCase(r rune, _ bool) rune {
+	return unicode.ToUpper(r)
+}
+
+func lowerCase(r rune, _ bool) rune {
+	return unicode.ToLower(r)
+}
+
+func titleCase(r rune, first bool) rune {
+	if first {
+		return unicode.ToTitle(r)
+	}
+	return unicode.ToLower(r)
+}
+
+func (e *Editor) ChangeCase(convert func(r rune, first bool) rune) {
+	if !e.Writable() {
+		return
+	}
+
+	changed := false
+	if sy, sx, ey, ex, ok := e.SelectionBounds(); ok {
+		for y := sy; y <= ey && y < len(e.Rows); y++ {
+			from, to := 0, len(e.Rows[y].chars)
+			if y == sy {
+				from = sx
+			}
+			if y == ey {
+				to = ex
+			}
+			if e.convertRun(y, from, to, convert) {
+				changed = true
+			}
+		}
+	} else {
+		if e.CY >= len(e.Rows) {
+			return
+		}
+		from, to := e.wordBounds(e.CY, e.CX)
+		if from == to {
+			e.SetStatusMessage("No word under the cursor")
+			return
+		}
+		changed = e.convertRun(e.CY, from, to, convert)
+	}
+
+	if changed {
+		e.Dirty++
+	}
+}
+
+func (e *Editor) convertRun(y, from, to int, convert func(r rune, first bool) rune) bool {
+	chars := e.Rows[y].chars
+	if from >= to {
+		return false
+	}
+
+	converted := make([]rune, 0, to-from)
+	first := from == 0 || IsSeparator(chars[from-1])
+	for _, r := range chars[from:to] {
+		converted = append(converted, convert(r, first))
+		first = IsSeparator(r)
+	}
+	if string(converted) == string(chars[from:to]) {
+		return false
+	}
+
+	e.DeleteRunes(y, from, to-from)
+	e.InsertRunes(y, from, converted)
+	return true
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.design/x/clipboard"
+)
+
+const osc52MaxPayload = 100000
+
+func (e *Editor) SetClipboard(text string) {
+	e.Clipboard = text
+	if !e.Config.UseOSC52 {
+		return
+	}
+
+	payload := base64.StdEncoding.EncodeToString([]byte(text))
+	if len(payload) > osc52MaxPayload {
+		Debugf("osc52 payload of %d bytes skipped", len(payload))
+		return
+	}
+	os.Stdout.WriteString("\x1b]52;c;" + payload + "\x07")
+}
+
+func (e *Editor) CopyLine() {
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	e.SetClipboard(string(e.Rows[e.CY].chars) + "\n")
+	e.SetStatusMessage("Copied line %d", e.CY+1)
+}
+
+func (e *Editor) CutLine() {
+	if !e.Writable() {
+		return
+	}
+
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	e.SetClipboard(string(e.Rows[e.CY].chars) + "\n")
+	e.DeleteRow(e.CY)
+	e.CX = 0
+	e.SetStatusMessage("Cut line %d", e.CY+1)
+}
+
+func (e *Editor) Paste() {
+	text := e.Clipboard
+	if text == "" {
+		text = string(clipboard.Read(clipboard.FmtText))
+	}
+	if text == "" {
+		return
+	}
+
+	e.InsertText(strings.ReplaceAll(text, "\r\n", "\n"))
+}
+
+func (e *Editor) InsertText(text string) {
+	if !e.Writable() {
+		return
+	}
+
+	lines := strings.Split(text, "\n")
+
+	if e.CY == len(e.Rows) {
+		if lines[len(lines)-1] == "" {
+			for _, line := range lines[:len(lines)-1] {
+				e.InsertRow(e.CY, line)
+				e.CY++
+			}
+			e.CX = 0
+			e.Dirty++
+			return
+		}
+		e.InsertRow(e.CY, "")
+	}
+
+	row := e.Rows[e.CY]
+	tail := append([]rune(nil), row.chars[e.CX:]...)
+	e.DeleteRunes(e.CY, e.CX, len(tail))
+
+	first := []rune(lines[0])
+	e.InsertRunes(e.CY, e.CX, first)
+	e.CX += len(first)
+
+	for _, line := range lines[1:] {
+		e.CY++
+		e.InsertRow(e.CY, line)
+		e.CX = utf8.RuneCountInString(line)
+	}
+
+	e.InsertRunes(e.CY, e.CX, tail)
+	e.Dirty++
+}
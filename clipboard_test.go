@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func rowText(e *Editor) string {
+	lines := make([]string, len(e.Rows))
+	for i, row := range e.Rows {
+		lines[i] = string(row.chars)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestInsertTextMidLine(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   string
+		cy, cx int
+	}{
+		{"XY", "heXYllo\nworld", 0, 4},
+		{"日本", "he日本llo\nworld", 0, 4},
+		{"X\nY", "heX\nYllo\nworld", 1, 1},
+		{"X\n\nYZ", "heX\n\nYZllo\nworld", 2, 2},
+		{"X\n", "heX\nllo\nworld", 1, 0},
+		{"\n", "he\nllo\nworld", 1, 0},
+	}
+
+	for _, tt := range tests {
+		e := newTestEditor("hello", "world")
+		e.CY, e.CX = 0, 2
+		e.InsertText(tt.text)
+		if got := rowText(e); got != tt.want {
+			t.Errorf("InsertText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if e.CY != tt.cy || e.CX != tt.cx {
+			t.Errorf("InsertText(%q): cursor = %d,%d, want %d,%d", tt.text, e.CY, e.CX, tt.cy, tt.cx)
+		}
+		if e.Dirty != 1 {
+			t.Errorf("InsertText(%q): Dirty = %d, want 1", tt.text, e.Dirty)
+		}
+	}
+}
+
+func TestInsertTextAtEndOfBuffer(t *testing.T) {
+	e := newTestEditor("hello")
+	e.CY, e.CX = 1, 0
+	e.InsertText("one\ntwo\n")
+	if got := rowText(e); got != "hello\none\ntwo" {
+		t.Fatalf("rows = %q", got)
+	}
+	if e.CY != 3 || e.CX != 0 {
+		t.Fatalf("cursor = %d,%d, want 3,0", e.CY, e.CX)
+	}
+
+	e.InsertText("three")
+	if got := rowText(e); got != "hello\none\ntwo\nthree" {
+		t.Fatalf("rows = %q", got)
+	}
+	if e.CY != 3 || e.CX != 5 {
+		t.Fatalf("cursor = %d,%d, want 3,5", e.CY, e.CX)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Color struct {
+	Index   uint8
+	RGB     bool
+	R, G, B uint8
+}
+
+func (c *Color) UnmarshalJSON(data []byte) error {
+	var index uint8
+	if err := json.Unmarshal(data, &index); err == nil {
+		*c = Color{Index: index}
+		return nil
+	}
+
+	var hex string
+	if err := json.Unmarshal(data, &hex); err != nil {
+		return fmt.Errorf("invalid color %s", data)
+	}
+
+	v, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil || len(hex) != 7 || hex[0] != '#' {
+		return fmt.Errorf("invalid color %q, expected #RRGGBB", hex)
+	}
+
+	*c = Color{RGB: true, R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}
+	c.Index = c.Nearest256()
+	return nil
+}
+
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func nearestCubeLevel(v uint8) int {
+	best := 0
+	for i, level := range cubeLevels {
+		if abs(int(v)-level) < abs(int(v)-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func colorDistance(r1, g1, b1, r2, g2, b2 int) int {
+	return (r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2)
+}
+
+func (c Color) Nearest256() uint8 {
+	r, g, b := nearestCubeLevel(c.R), nearestCubeLevel(c.G), nearestCubeLevel(c.B)
+	cube := 16 + 36*r + 6*g + b
+	cubeDist := colorDistance(int(c.R), int(c.G), int(c.B), cubeLevels[r], cubeLevels[g], cubeLevels[b])
+
+	avg := (int(c.R) + int(c.G) + int(c.B)) / 3
+	gray := (avg - 8 + 5) / 10
+	if gray < 0 {
+		gray = 0
+	}
+	if gray > 23 {
+		gray = 23
+	}
+	level := 8 + gray*10
+	grayDist := colorDistance(int(c.R), int(c.G), int(c.B), level, level, level)
+
+	if grayDist < cubeDist {
+		return uint8(232 + gray)
+	}
+	return uint8(cube)
+}
+
+func (c Color) sequence(layer int, trueColor bool) string {
+	if c.RGB && trueColor {
+		return fmt.Sprintf("\x1b[%d;2;%d;%d;%dm", layer, c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("\x1b[%d;5;%dm", layer, c.Index)
+}
+
+func (e *Editor) TrueColor() bool {
+	if e.Config.TrueColor {
+		return true
+	}
+	term := os.Getenv("COLORTERM")
+	return term == "truecolor" || term == "24bit"
+}
+
+func (e *Editor) Fg(c Color) string {
+	return c.sequence(38, e.TrueColor())
+}
+
+func (e *Editor) Bg(c Color) string {
+	return c.sequence(48, e.TrueColor())
+}
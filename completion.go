@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const maxPopupItems = 8
+
+func completePath(input string) (dir string, candidates []string) {
+	i := strings.LastIndex(input, "/")
+	dir, prefix := input[:i+1], input[i+1:]
+
+	readDir := ExpandPath(dir)
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return dir, nil
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+	return dir, candidates
+}
+
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+func (e *Editor) PromptFilename(prompt string) (string, error) {
+	var (
+		dir        string
+		candidates []string
+		current    = -1
+	)
+
+	filename, err := e.PromptEdit(func() string { return prompt }, func(query string, k key) string {
+		if k != key('\t') {
+			candidates = nil
+			return query
+		}
+
+		if len(candidates) > 1 {
+			current = (current + 1) % len(candidates)
+			return dir + candidates[current]
+		}
+
+		dir, candidates = completePath(query)
+		current = -1
+		switch len(candidates) {
+		case 0:
+			return query
+		case 1:
+			completed := dir + candidates[0]
+			candidates = nil
+			return completed
+		}
+
+		if prefix := dir + commonPrefix(candidates); len(prefix) > len(query) {
+			return prefix
+		}
+		current = 0
+		return dir + candidates[0]
+	})
+	if err != nil {
+		return "", err
+	}
+	return ExpandPath(filename), nil
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (e *Editor) wordCandidates(prefix string) []string {
+	type candidate struct {
+		word     string
+		distance int
+	}
+
+	seen := map[string]int{}
+	var found []candidate
+	for y, row := range e.Rows {
+		if row == nil {
+			continue
+		}
+		distance := y - e.CY
+		if distance < 0 {
+			distance = -distance
+		}
+
+		chars := row.chars
+		for x := 0; x < len(chars); {
+			if !isWordRune(chars[x]) {
+				x++
+				continue
+			}
+			start := x
+			for x < len(chars) && isWordRune(chars[x]) {
+				x++
+			}
+
+			word := string(chars[start:x])
+			if len(word) <= len(prefix) || !strings.HasPrefix(word, prefix) {
+				continue
+			}
+			if i, ok := seen[word]; ok {
+				if distance < found[i].distance {
+					found[i].distance = distance
+				}
+				continue
+			}
+			seen[word] = len(found)
+			found = append(found, candidate{word, distance})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+	words := make([]string, len(found))
+	for i, c := range found {
+		words[i] = c.word
+	}
+	return words
+}
+
+func (e *Editor) CompleteWord() error {
+	if !e.Writable() {
+		return nil
+	}
+
+	if e.CY >= len(e.Rows) {
+		return nil
+	}
+
+	chars := e.Rows[e.CY].chars
+	start := e.CX
+	for start > 0 && isWordRune(chars[start-1]) {
+		start--
+	}
+	prefix := string(chars[start:e.CX])
+	if prefix == "" {
+		e.SetStatusMessage("Nothing to complete")
+		return nil
+	}
+
+	candidates := e.wordCandidates(prefix)
+	if len(candidates) == 0 {
+		e.SetStatusMessage("No completions for %s", prefix)
+		return nil
+	}
+
+	defer func() { e.popup = nil }()
+	inserted := 0
+	choose := func(i int) {
+		e.DeleteRunes(e.CY, e.CX-inserted, inserted)
+		e.CX -= inserted
+		suffix := []rune(candidates[i][len(prefix):])
+		e.InsertRunes(e.CY, e.CX, suffix)
+		e.CX += len(suffix)
+		inserted = len(suffix)
+	}
+
+	current := 0
+	for {
+		choose(current)
+		if len(candidates) == 1 {
+			e.Dirty++
+			return nil
+		}
+
+		e.popup, e.popupSel = candidates, current
+		e.SetStatusMessage("Complete [%d/%d] (Alt-/ or Tab = Next | Enter = Accept | ESC = Cancel)", current+1, len(candidates))
+		e.Render()
+
+		k, err := e.WaitKey()
+		if err != nil {
+			return err
+		}
+
+		switch k {
+		case alt('/'), key('\t'), keyArrowDown:
+			current = (current + 1) % len(candidates)
+		case keyArrowUp:
+			current = (current + len(candidates) - 1) % len(candidates)
+		case key('\x1b'):
+			e.DeleteRunes(e.CY, e.CX-inserted, inserted)
+			e.CX -= inserted
+			e.SetStatusMessage("")
+			return nil
+		case keyEnter:
+			e.Dirty++
+			e.SetStatusMessage("")
+			return nil
+		default:
+			e.Dirty++
+			e.SetStatusMessage("")
+			e.UnreadKey(k)
+			return nil
+		}
+	}
+}
+
+func (e *Editor) DrawPopup(b *strings.Builder, cy, cx int) {
+	if len(e.popup) == 0 {
+		return
+	}
+
+	first := 0
+	if e.popupSel >= maxPopupItems {
+		first = e.popupSel - maxPopupItems + 1
+	}
+	last := first + maxPopupItems
+	if last > len(e.popup) {
+		last = len(e.popup)
+	}
+
+	width := 0
+	for _, item := range e.popup[first:last] {
+		if w := runewidth.StringWidth(item); w > width {
+			width = w
+		}
+	}
+	width += 2
+	if width > e.ScreenCols {
+		width = e.ScreenCols
+	}
+	if cx+width > e.ScreenCols {
+		cx = e.ScreenCols - width
+	}
+
+	top := cy + 1
+	if top+last-first > e.ScreenRows {
+		top = cy - (last - first)
+	}
+	if top < 0 {
+		top = 0
+	}
+
+	for i, item := range e.popup[first:last] {
+		style := e.Bg(e.Theme.CurrentLine)
+		if first+i == e.popupSel {
+			style = "\x1b[7m"
+		}
+		item = runewidth.Truncate(item, width-2, "")
+		b.WriteString(fmt.Sprintf("\x1b[%d;%dH%s %s \x1b[m", top+i+1, cx+1, style, runewidth.FillRight(item, width-2)))
+		if top+i < len(e.prevFrame) {
+			e.prevFrame[top+i] = ""
+		}
+	}
+}
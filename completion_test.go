@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestCompletionKeepsTypedKey(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor("foobar", "fo")
+	e.CY, e.CX = 1, 2
+
+	typeKeys(t, e, alt('/'), key('x'))
+	if got := string(e.Rows[1].chars); got != "foobarx" {
+		t.Fatalf("line = %q, want %q", got, "foobarx")
+	}
+}
+
+func TestCompletionEnterAccepts(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor("foobar", "foobaz", "fo")
+	e.CY, e.CX = 2, 2
+
+	typeKeys(t, e, alt('/'), keyEnter)
+	if len(e.Rows) != 3 {
+		t.Fatalf("Enter inserted a line: %d rows", len(e.Rows))
+	}
+	if got := string(e.Rows[2].chars); got != "foobaz" && got != "foobar" {
+		t.Fatalf("line = %q, want a completion", got)
+	}
+}
@@ -3,22 +3,65 @@ package main
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"strconv"
 	"time"
 
 	"github.com/olekukonko/ts"
 	"golang.design/x/clipboard"
+	"golang.org/x/sys/unix"
 )
 
 const CONFIG_FILE = ".config/cookie/config.json"
 const SYNTAX_FILE = ".config/cookie/syntax.json"
+const COLOR_THEMES_DIR = ".config/cookie/color-themes"
 
 type Config struct {
-	TabStop       int          `json:"tab_stop"`
-	QuitTimes     int          `json:"quit_times"`
-	EmptyLineChar string       `json:"empty_line_char"`
-	ColorPalette  ColorPalette `json:"color_palette"`
+	ColorTheme                  string       `json:"color_theme"`
+	TabStop                     int          `json:"tab_stop"`
+	QuitTimes                   int          `json:"quit_times"`
+	EmptyLineChar               string       `json:"empty_line_char"`
+	WideCharBoundary            string       `json:"wide_char_boundary"`
+	IdleDelay                   int          `json:"idle_delay"`
+	StickyColOffset             bool         `json:"sticky_col_offset"`
+	ColOffsetMargin             int          `json:"col_offset_margin"`
+	DebugLog                    bool         `json:"debug_log"`
+	ShowLineNumbers             bool         `json:"show_line_numbers"`
+	RelativeLineNumbers         bool         `json:"relative_line_numbers"`
+	RegexSearch                 bool         `json:"regex_search"`
+	AutoIndent                  bool         `json:"auto_indent"`
+	EnsureFinalNewline          bool         `json:"ensure_final_newline"`
+	LineEnding                  string       `json:"line_ending"`
+	SoftTabs                    bool         `json:"soft_tabs"`
+	KeepBOM                     bool         `json:"keep_bom"`
+	UseOSC52                    bool         `json:"use_osc52"`
+	ShowTabBar                  bool         `json:"show_tab_bar"`
+	HighlightCurrentLine        bool         `json:"highlight_current_line"`
+	ReadOnly                    bool         `json:"read_only"`
+	AutoSaveSeconds             int          `json:"auto_save_seconds"`
+	LargeFileMB                 int          `json:"large_file_mb"`
+	TrueColor                   bool         `json:"true_color"`
+	ShowColumn                  bool         `json:"show_column"`
+	ShowPercentage              bool         `json:"show_percentage"`
+	CursorShape                 string       `json:"cursor_shape"`
+	ScrollOff                   int          `json:"scroll_off"`
+	WarnMixedIndent             bool         `json:"warn_mixed_indent"`
+	ShowWhitespace              bool         `json:"show_whitespace"`
+	WordWrap                    bool         `json:"word_wrap"`
+	ColorColumn                 []int        `json:"color_column"`
+	ElectricBraces              bool         `json:"electric_braces"`
+	GitSigns                    bool         `json:"git_signs"`
+	ShowGitBranch               bool         `json:"show_git_branch"`
+	HighlightTrailingWhitespace bool         `json:"highlight_trailing_whitespace"`
+	DateFormat                  string       `json:"date_format"`
+	JoinWithSpace               bool         `json:"join_with_space"`
+	NewFileMode                 string       `json:"new_file_mode"`
+	MaxUndoLevels               int          `json:"max_undo_levels"`
+	MaxUndoMemory               int          `json:"max_undo_memory"`
+	ColorPalette                ColorPalette `json:"color_palette"`
 }
 
 func HandleConfig() (*Config, error) {
@@ -44,14 +87,51 @@ func HandleConfig() (*Config, error) {
 	if err != nil {
 		return &Config{}, errors.New("failed to read config file")
 	}
+	defer file.Close()
 
 	if err := json.NewDecoder(file).Decode(config); err != nil {
-		return &Config{}, errors.New("failed to decode config file")
+		return &Config{}, fmt.Errorf("failed to decode config file: %v", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return &Config{}, err
 	}
 
 	return config, nil
 }
 
+func (c *Config) Validate() error {
+	if c.TabStop <= 0 {
+		return errors.New("invalid config: tab_stop must be greater than zero")
+	}
+
+	if c.QuitTimes < 0 {
+		return errors.New("invalid config: quit_times must not be negative")
+	}
+
+	if c.MaxUndoLevels < 0 || c.MaxUndoMemory < 0 {
+		return errors.New("invalid config: max_undo_levels and max_undo_memory must not be negative")
+	}
+
+	if _, _, err := c.FileMode(); err != nil {
+		return errors.New("invalid config: new_file_mode must be an octal mode such as \"0644\"")
+	}
+
+	return nil
+}
+
+func (c *Config) FileMode() (os.FileMode, bool, error) {
+	if c.NewFileMode == "" {
+		return 0666, false, nil
+	}
+
+	mode, err := strconv.ParseUint(c.NewFileMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, false, errors.New("invalid file mode")
+	}
+	return os.FileMode(mode), true, nil
+}
+
 func HandleSyntax() ([]*EditorSyntax, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -77,12 +157,85 @@ func HandleSyntax() ([]*EditorSyntax, error) {
 	}
 
 	if err := json.Unmarshal(file, &syntax); err != nil {
-		return nil, errors.New("failed to unmarshal config file")
+		return nil, fmt.Errorf("failed to unmarshal syntax file: %v", err)
 	}
 
 	return syntax, nil
 }
 
+func HandleTheme(config *Config) (*ColorPalette, error) {
+	theme := config.ColorPalette
+	if config.ColorTheme == "" {
+		return &theme, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.New("failed to get home directory")
+	}
+
+	themesDir := homeDir + "/" + COLOR_THEMES_DIR
+	defaultTheme := themesDir + "/default.json"
+	if _, err := os.Stat(defaultTheme); err != nil {
+		if err := os.MkdirAll(themesDir, 0755); err != nil {
+			return nil, errors.New("failed to create color themes directory")
+		}
+
+		if err := ioutil.WriteFile(defaultTheme, []byte(startingThemeJson), 0644); err != nil {
+			return nil, errors.New("failed to create default color theme")
+		}
+	}
+
+	file, err := ioutil.ReadFile(themesDir + "/" + config.ColorTheme + ".json")
+	if err != nil {
+		Debugf("color theme %q not found, using config palette", config.ColorTheme)
+		return &theme, nil
+	}
+
+	if err := json.Unmarshal(file, &theme); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal color theme %s: %v", config.ColorTheme, err)
+	}
+
+	return &theme, nil
+}
+
+func (e *Editor) ReloadConfig() error {
+	config, err := HandleConfig()
+	if err != nil {
+		return err
+	}
+
+	syntax, err := HandleSyntax()
+	if err != nil {
+		return err
+	}
+
+	theme, err := HandleTheme(config)
+	if err != nil {
+		return err
+	}
+
+	keymap, err := HandleKeymap()
+	if err != nil {
+		return err
+	}
+
+	e.Config = config
+	e.Syntaxes = syntax
+	e.Theme = theme
+	e.Keymap = keymap
+	e.SetSnippets(HandleSnippets())
+
+	current := e.Buffer
+	for _, buf := range e.Buffers {
+		e.Buffer = buf
+		e.SelectSyntaxHighlight()
+	}
+	e.Buffer = current
+
+	return nil
+}
+
 func main() {
 	var editor Editor
 
@@ -96,25 +249,63 @@ func main() {
 		die(err)
 	}
 
+	theme, err := HandleTheme(config)
+	if err != nil {
+		die(err)
+	}
+
+	keymap, err := HandleKeymap()
+	if err != nil {
+		die(err)
+	}
+
 	editor.Config = config
 	editor.Syntaxes = syntax
+	editor.Theme = theme
+	editor.Keymap = keymap
+
+	if err := OpenDebugLog(config.DebugLog); err != nil {
+		die(err)
+	}
+	defer CloseDebugLog()
 
 	go func() {
 		for {
+			time.Sleep(time.Second * 5)
+
 			config, err := HandleConfig()
 			if err != nil {
-				die(err)
+				Debugf("config reload failed: %v", err)
+				continue
 			}
 
 			syntax, err := HandleSyntax()
 			if err != nil {
-				die(err)
+				Debugf("syntax reload failed: %v", err)
+				continue
 			}
 
+			theme, err := HandleTheme(config)
+			if err != nil {
+				Debugf("color theme reload failed: %v", err)
+				continue
+			}
+
+			snippets, snippetsErr := HandleSnippets()
+
+			keymap, err := HandleKeymap()
+			if err != nil {
+				Debugf("key bindings reload failed: %v", err)
+				continue
+			}
+
+			editor.mu.Lock()
 			editor.Config = config
 			editor.Syntaxes = syntax
-
-			time.Sleep(time.Second * 5)
+			editor.Theme = theme
+			editor.Keymap = keymap
+			editor.SetSnippets(snippets, snippetsErr)
+			editor.mu.Unlock()
 		}
 	}()
 
@@ -123,35 +314,117 @@ func main() {
 	}
 	defer editor.Close()
 
-	if len(os.Args) > 1 {
-		err := editor.OpenFile(os.Args[1])
-		if err != nil && !errors.Is(err, os.ErrNotExist) {
-			die(err)
+	editor.SetStatusMessage("Help: Ctrl-S = Save | Ctrl-Q = Quit | Ctrl-F = Find | Ctrl-D = Delete Line | Ctrl-Home/End = Top/Bottom")
+	editor.SetSnippets(HandleSnippets())
+
+	var args []string
+	for _, arg := range os.Args[1:] {
+		if arg == "--force" {
+			editor.ForceBinary = true
+			continue
 		}
+		if arg == "--hex" {
+			editor.HexMode = true
+			continue
+		}
+		if arg == "--print-summary" {
+			editor.PrintSummary = true
+			continue
+		}
+		args = append(args, arg)
 	}
 
-	editor.SetStatusMessage("Help: Ctrl-S = Save | Ctrl-Q = Quit | Ctrl-F = Find | Ctrl-D = Delete Line")
+	editor.mu.Lock()
+	editor.NewBuffer()
+	if len(args) > 0 && args[0] == "--restore" {
+		if err := editor.RestoreSession(); err != nil {
+			die(err)
+		}
+	} else {
+		for i, filename := range args {
+			if i > 0 {
+				editor.NewBuffer()
+			}
+
+			err := editor.OpenFile(filename)
+			if errors.Is(err, ErrIsDirectory) {
+				picked, pickErr := editor.Browse(filename)
+				if pickErr != nil && pickErr != ErrPromptCanceled {
+					die(pickErr)
+				}
+				err = nil
+				if picked != "" {
+					err = editor.OpenFile(picked)
+				}
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				die(err)
+			}
+			editor.RestoreCursorFromHistory()
+			if editor.HexMode {
+				if err := editor.EnterHexMode(); err != nil {
+					die(err)
+				}
+			}
+		}
+		editor.Buffer = editor.Buffers[0]
+	}
+	editor.mu.Unlock()
 
 	clipboard.Init()
 
+	editor.OnIdle(editor.RehighlightAll)
+	editor.OnIdle(editor.CheckDiskChanges)
+	editor.OnIdle(editor.WriteSwapFiles)
+	editor.AddSignProvider(bookmarkSigns{})
+	editor.AddSignProvider(gitSigns{})
+
 	go func() {
 		for {
-			size, _ := ts.GetSize()
-			editor.ScreenCols = size.Col()
-			editor.ScreenRows = size.Row() - 2
-
-			editor.Render()
+			editor.RunIdle()
 			time.Sleep(time.Millisecond * 100)
 		}
 	}()
 
+	go func() {
+		for {
+			editor.AutoSave()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, unix.SIGWINCH)
+
+	go func() {
+		for range winch {
+			size, err := ts.GetSize()
+			if err != nil {
+				continue
+			}
+
+			editor.Resize(size.Col(), size.Row()-2)
+		}
+	}()
+
 	for {
+		editor.mu.Lock()
 		editor.Render()
+		editor.mu.Unlock()
+
 		if err := editor.ProcessKey(); err != nil {
 			if err == ErrQuitEditor {
 				break
 			}
+			Debugf("fatal error: %v", err)
 			die(err)
 		}
 	}
+
+	if editor.PrintSummary {
+		editor.Close()
+		CloseDebugLog()
+		fmt.Fprintln(os.Stderr, editor.Summary())
+		os.Exit(editor.ExitCode())
+	}
 }
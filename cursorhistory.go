@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+)
+
+const CURSOR_HISTORY_FILE = ".config/cookie/cursor_history.json"
+const maxCursorHistory = 200
+
+type cursorRecord struct {
+	Filename string `json:"filename"`
+	CX       int    `json:"cx"`
+	CY       int    `json:"cy"`
+}
+
+func loadCursorHistory() []cursorRecord {
+	path, err := homePath(CURSOR_HISTORY_FILE)
+	if err != nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var history []cursorRecord
+	if err := json.Unmarshal(data, &history); err != nil {
+		Debugf("cursor history is corrupt: %v", err)
+		return nil
+	}
+	return history
+}
+
+func saveCursorHistory(history []cursorRecord) error {
+	path, err := homePath(CURSOR_HISTORY_FILE)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (e *Editor) absFilename() string {
+	filename, err := filepath.Abs(e.Filename)
+	if err != nil {
+		return e.Filename
+	}
+	return filename
+}
+
+func (e *Editor) RecordCursor() {
+	if e.Filename == "" {
+		return
+	}
+
+	filename := e.absFilename()
+	history := []cursorRecord{{Filename: filename, CX: e.CX, CY: e.CY}}
+	for _, r := range loadCursorHistory() {
+		if r.Filename != filename && len(history) < maxCursorHistory {
+			history = append(history, r)
+		}
+	}
+
+	if err := saveCursorHistory(history); err != nil {
+		Debugf("save cursor history failed: %v", err)
+	}
+}
+
+func (e *Editor) RestoreCursorFromHistory() {
+	filename := e.absFilename()
+	for _, r := range loadCursorHistory() {
+		if r.Filename == filename {
+			offset := r.CY - e.TextRows()/2
+			if offset < 0 {
+				offset = 0
+			}
+			e.RestoreCursor(r.CX, r.CY, offset)
+			return
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCursorHistoryRecordedOnClose(t *testing.T) {
+	silenceStdout(t)
+	t.Setenv("HOME", t.TempDir())
+	if err := os.MkdirAll(filepath.Join(os.Getenv("HOME"), ".config/cookie"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "history.txt")
+	if err := os.WriteFile(filename, []byte("one\ntwo\nthree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEditor()
+	if err := e.OpenInNewBuffer(filename); err != nil {
+		t.Fatal(err)
+	}
+	e.CY, e.CX = 2, 3
+	if _, err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if history := loadCursorHistory(); len(history) != 0 {
+		t.Fatalf("save wrote cursor history: %+v", history)
+	}
+
+	typeKeys(t, e, keyFor(t, "quit"))
+	if history := loadCursorHistory(); len(history) != 1 || history[0].CY != 2 || history[0].CX != 3 {
+		t.Fatalf("history after closing = %+v", loadCursorHistory())
+	}
+
+	if err := e.OpenFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if e.CY != 0 || e.CX != 0 {
+		t.Fatalf("OpenFile moved the cursor to %d,%d", e.CY, e.CX)
+	}
+
+	if err := e.OpenInNewBuffer(filename); err != nil {
+		t.Fatal(err)
+	}
+	if e.CY != 2 || e.CX != 3 {
+		t.Fatalf("cursor = %d,%d after reopening, want 2,3", e.CY, e.CX)
+	}
+}
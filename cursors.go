@@ -0,0 +1,190 @@
+package main
+
+import "unicode"
+
+type cursor struct {
+	x, y int
+}
+
+func isMultiCursorAction(action string, k key) bool {
+	switch action {
+	case "newline", "backspace", "delete", "tab":
+		return true
+	}
+	return action == "" && k < keyArrowLeft && !unicode.IsControl(rune(k))
+}
+
+func (e *Editor) EditAtCursor(action string, k key) {
+	switch action {
+	case "newline":
+		e.InsertNewline()
+	case "tab":
+		e.InsertTab()
+	case "backspace":
+		e.Backspace()
+	case "delete":
+		e.DeleteForward()
+	default:
+		e.InsertChar(rune(k))
+	}
+}
+
+func (e *Editor) CollapseCursors() {
+	e.cursors = nil
+}
+
+func (e *Editor) HasCursorAt(x, y int) bool {
+	if e.CX == x && e.CY == y {
+		return true
+	}
+	for _, c := range e.cursors {
+		if c.x == x && c.y == y {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Editor) wordBounds(y, x int) (int, int) {
+	chars := e.RowAt(y).chars
+	start, end := x, x
+	for start > 0 && !IsSeparator(chars[start-1]) {
+		start--
+	}
+	for end < len(chars) && !IsSeparator(chars[end]) {
+		end++
+	}
+	return start, end
+}
+
+func (e *Editor) AddCursor() {
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	start, end := e.wordBounds(e.CY, e.CX)
+	if start == end {
+		e.addCursorBelow()
+		return
+	}
+
+	word := e.RowAt(e.CY).chars[start:end]
+	offset := e.CX - start
+	for i := 1; i <= len(e.Rows); i++ {
+		y := (e.CY + i) % len(e.Rows)
+		chars := e.RowAt(y).chars
+		from := 0
+		if y == e.CY {
+			from = end
+			if i == len(e.Rows) {
+				from = 0
+			}
+		}
+
+		for x := from; x+len(word) <= len(chars); x++ {
+			if string(chars[x:x+len(word)]) != string(word) {
+				continue
+			}
+			if s, en := e.wordBounds(y, x); s != x || en != x+len(word) {
+				continue
+			}
+			if e.HasCursorAt(x+offset, y) {
+				continue
+			}
+			e.pushCursor(x+offset, y)
+			return
+		}
+	}
+
+	e.SetStatusMessage("No more occurrences of %s", string(word))
+}
+
+func (e *Editor) addCursorBelow() {
+	y := e.CY
+	for _, c := range e.cursors {
+		if c.y > y {
+			y = c.y
+		}
+	}
+	if y+1 >= len(e.Rows) {
+		e.SetStatusMessage("No line below")
+		return
+	}
+
+	x := e.CX
+	if x > len(e.Rows[y+1].chars) {
+		x = len(e.Rows[y+1].chars)
+	}
+	e.pushCursor(x, y+1)
+}
+
+func (e *Editor) pushCursor(x, y int) {
+	e.cursors = append(e.cursors, cursor{e.CX, e.CY})
+	e.CX, e.CY = x, y
+	e.SetStatusMessage("%d cursors (ESC to collapse)", len(e.cursors)+1)
+}
+
+func (e *Editor) ApplyAtCursors(op func()) {
+	all := append([]cursor{{e.CX, e.CY}}, e.cursors...)
+	for i := range all {
+		x, y := all[i].x, all[i].y
+		rows, length := len(e.Rows), 0
+		if y < len(e.Rows) {
+			length = len(e.Rows[y].chars)
+		}
+
+		e.CX, e.CY = x, y
+		op()
+		nx, ny := e.CX, e.CY
+		all[i] = cursor{nx, ny}
+
+		for j := range all {
+			if j == i {
+				continue
+			}
+			c := &all[j]
+			switch {
+			case len(e.Rows) > rows:
+				if c.y == y && c.x >= x {
+					c.x, c.y = c.x-x+nx, ny
+				} else if c.y > y {
+					c.y++
+				}
+			case len(e.Rows) < rows:
+				if c.y == ny+1 {
+					c.x, c.y = c.x+nx, ny
+				} else if c.y > ny+1 {
+					c.y--
+				}
+			case c.y == y && y < len(e.Rows):
+				if c.x >= x {
+					c.x += len(e.Rows[y].chars) - length
+				} else if c.x > nx {
+					c.x = nx
+				}
+			}
+		}
+	}
+
+	e.CX, e.CY = all[0].x, all[0].y
+	e.cursors = nil
+	for _, c := range all[1:] {
+		if !e.HasCursorAt(c.x, c.y) {
+			e.cursors = append(e.cursors, c)
+		}
+	}
+}
+
+func (e *Editor) CursorCells(row *Row) map[int]bool {
+	var cells map[int]bool
+	for _, c := range e.cursors {
+		if c.y != row.idx {
+			continue
+		}
+		if cells == nil {
+			cells = make(map[int]bool)
+		}
+		cells[e.RowCxToRenderIdx(row, c.x)] = true
+	}
+	return cells
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestSingleCursorEditCollapsesCursors(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor("foo", "foo")
+	e.CX = 1
+	e.AddCursor()
+	if len(e.cursors) != 1 {
+		t.Fatalf("%d extra cursors, want 1", len(e.cursors))
+	}
+
+	typeKeys(t, e, keyArrowLeft)
+	if len(e.cursors) != 1 {
+		t.Fatal("moving collapsed the cursors")
+	}
+
+	e.Clipboard = "z"
+	typeKeys(t, e, keyFor(t, "paste"))
+	if len(e.cursors) != 0 {
+		t.Fatal("paste left the extra cursors in place")
+	}
+	if got := e.RowsToString(); got != "foo\nzfoo\n" {
+		t.Fatalf("buffer = %q", got)
+	}
+}
+
+func TestTypingAtEveryCursor(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor("foo bar", "foo baz")
+	e.CX = 1
+	e.AddCursor()
+
+	typeKeys(t, e, key('x'))
+	if got := e.RowsToString(); got != "fxoo bar\nfxoo baz\n" {
+		t.Fatalf("buffer = %q", got)
+	}
+	if len(e.cursors) != 1 {
+		t.Fatalf("%d extra cursors after typing, want 1", len(e.cursors))
+	}
+}
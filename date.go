@@ -0,0 +1,14 @@
+package main
+
+import "time"
+
+const defaultDateFormat = "2006-01-02 15:04"
+
+func (e *Editor) InsertDate() {
+	format := e.Config.DateFormat
+	if format == "" {
+		format = defaultDateFormat
+	}
+
+	e.InsertText(time.Now().Format(format))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const DEBUG_LOG_FILE = ".config/cookie/debug.log"
+
+var debugEnabled int32
+
+var debugLog struct {
+	sync.Mutex
+	file *os.File
+}
+
+func OpenDebugLog(enabled bool) error {
+	if os.Getenv("COOKIE_DEBUG") != "" {
+		enabled = true
+	}
+	if !enabled {
+		return nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory")
+	}
+
+	if err := os.MkdirAll(homeDir+"/.config/cookie", 0755); err != nil {
+		return fmt.Errorf("failed to create config directory")
+	}
+
+	file, err := os.OpenFile(homeDir+"/"+DEBUG_LOG_FILE, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open debug log")
+	}
+
+	debugLog.Lock()
+	debugLog.file = file
+	debugLog.Unlock()
+	atomic.StoreInt32(&debugEnabled, 1)
+
+	Debugf("debug log opened, version %s", version)
+	return nil
+}
+
+func CloseDebugLog() {
+	atomic.StoreInt32(&debugEnabled, 0)
+
+	debugLog.Lock()
+	defer debugLog.Unlock()
+
+	if debugLog.file != nil {
+		debugLog.file.Close()
+		debugLog.file = nil
+	}
+}
+
+func Debugf(format string, a ...interface{}) {
+	if atomic.LoadInt32(&debugEnabled) == 0 {
+		return
+	}
+
+	debugLog.Lock()
+	defer debugLog.Unlock()
+
+	if debugLog.file == nil {
+		return
+	}
+	fmt.Fprintf(debugLog.file, "%s %s\n", time.Now().Format("2006-01-02 15:04:05.000"), fmt.Sprintf(format, a...))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"time"
+)
+
+type diskState struct {
+	modTime time.Time
+	size    int64
+	known   bool
+	warned  bool
+}
+
+func (e *Editor) RecordDiskState() {
+	info, err := os.Stat(e.Filename)
+	if err != nil {
+		e.disk = diskState{}
+		return
+	}
+	e.disk = diskState{modTime: info.ModTime(), size: info.Size(), known: true}
+}
+
+func (e *Editor) ChangedOnDisk() bool {
+	if !e.disk.known || e.Filename == "" {
+		return false
+	}
+
+	info, err := os.Stat(e.Filename)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().Equal(e.disk.modTime) || info.Size() != e.disk.size
+}
+
+func (e *Editor) CheckDiskChanges() {
+	if e.disk.warned || !e.ChangedOnDisk() {
+		return
+	}
+
+	e.disk.warned = true
+	e.SetStatusMessage("\x1b[33;1mWARNING\x1b[0m %s changed on disk. Alt-L to reload.", e.DisplayName())
+	e.Render()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiskChangeWarningIsDrawn(t *testing.T) {
+	e := openTestFile(t, "watched.txt", "one\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(e.Filename, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "screen"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = out
+	e.CheckDiskChanges()
+	os.Stdout = stdout
+	out.Close()
+
+	screen, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(screen), "WARNING") {
+		t.Fatalf("warning not drawn: %q", screen)
+	}
+}
@@ -9,59 +9,98 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/mattn/go-runewidth"
-	"golang.design/x/clipboard"
 	"golang.org/x/sys/unix"
 )
 
 type key int32
 
 type Editor struct {
-	CX, CY            int
-	RX                int
-	RowOffset         int
-	ColOffset         int
+	*Buffer
+	Buffers           []*Buffer
 	ScreenRows        int
 	ScreenCols        int
-	Rows              []*Row
-	Dirty             int
 	QuitCounter       int
-	Filename          string
 	StatusMessage     string
 	StatusMessageTime time.Time
-	Syntax            *EditorSyntax
 	Term              *unix.Termios
 	Config            *Config
 	Syntaxes          []*EditorSyntax
+	Snippets          map[string]map[string]string
+	Keymap            map[key]string
+	ForceBinary       bool
+	HexMode           bool
+	PrintSummary      bool
+	Theme             *ColorPalette
+	Clipboard         string
+	SearchIgnoreCase  bool
+
+	mu              sync.Mutex
+	lastInput       time.Time
+	idleFired       bool
+	prompting       bool
+	pendingKeys     []key
+	snippetsErr     string
+	prevFrame       []string
+	lastAutoSave    time.Time
+	jumps           []jump
+	jumpIdx         int
+	popup           []string
+	popupSel        int
+	popupBottom     bool
+	browser         *fileBrowser
+	windows         []*window
+	winIdx          int
+	curWin          int
+	splitHorizontal bool
+	savedFiles      []string
+	bytesWritten    int
+	discarded       []string
+	idleCallbacks   []func()
+	signProviders   []SignProvider
 }
 
 type ColorPalette struct {
-	Normal           uint8 `json:"normal"`
-	Comment          uint8 `json:"comment"`
-	MultiLineComment uint8 `json:"multiline_comment"`
-	Keyword1         uint8 `json:"keyword1"`
-	Keyword2         uint8 `json:"keyword2"`
-	String           uint8 `json:"string"`
-	Number           uint8 `json:"number"`
-	Boolean          uint8 `json:"boolean"`
-	Match            uint8 `json:"match"`
+	Normal           Color `json:"normal"`
+	Comment          Color `json:"comment"`
+	MultiLineComment Color `json:"multiline_comment"`
+	Keyword1         Color `json:"keyword1"`
+	Keyword2         Color `json:"keyword2"`
+	String           Color `json:"string"`
+	Number           Color `json:"number"`
+	Boolean          Color `json:"boolean"`
+	Match            Color `json:"match"`
+	SearchAll        Color `json:"search_all"`
+	LineNumber       Color `json:"line_number"`
+	CurrentLine      Color `json:"current_line"`
+	MixedIndent      Color `json:"mixed_indent"`
+	ColorColumn      Color `json:"color_column"`
+	Bookmark         Color `json:"bookmark"`
+	GitAdded         Color `json:"git_added"`
+	GitModified      Color `json:"git_modified"`
+	GitRemoved       Color `json:"git_removed"`
 }
 
 type EditorSyntax struct {
-	FileType  string   `json:"filetype"`
-	FileMatch []string `json:"filematch"`
-	Keywords  []string `json:"keywords"`
-	SCS       string   `json:"scs"`
-	MCS       string   `json:"mcs"`
-	MCE       string   `json:"mce"`
+	FileType  string        `json:"filetype"`
+	FileMatch []string      `json:"filematch"`
+	Keywords  []string      `json:"keywords"`
+	SCS       string        `json:"scs"`
+	MCS       string        `json:"mcs"`
+	MCE       string        `json:"mce"`
+	MLS       []string      `json:"mls"`
+	Numbers   NumberFormats `json:"numbers"`
+	TabStop   int           `json:"tab_stop"`
 	Flags     struct {
-		HighLightNumbers  bool `json:"highlight_numbers"`
-		HighLightStrings  bool `json:"highlight_strings"`
-		HighLightBooleans bool `json:"highlight_booleans"`
+		HighLightNumbers          bool `json:"highlight_numbers"`
+		HighLightStrings          bool `json:"highlight_strings"`
+		HighLightBooleans         bool `json:"highlight_booleans"`
+		HighLightMultiLineStrings bool `json:"highlight_multiline_strings"`
 	} `json:"flags"`
 }
 
@@ -71,6 +110,15 @@ type Row struct {
 	render             string
 	hl                 []uint8
 	hasUnclosedComment bool
+	unclosedString     string
+	hlValid            bool
+	hlInComment        bool
+	hlInString         string
+	mixedIndent        bool
+	hlSyntax           *EditorSyntax
+	fold               int
+	hidden             bool
+	gitSign            rune
 }
 
 var version = "0.1.4"
@@ -82,6 +130,8 @@ var (
 
 var ErrQuitEditor = errors.New("quit editor")
 
+const utf8BOM = "\xef\xbb\xbf"
+
 const (
 	keyEnter     key = 10
 	keyBackspace key = 127
@@ -95,8 +145,20 @@ const (
 	keyPageDown
 	keyHome
 	keyEnd
+	keyAltArrowUp
+	keyAltArrowDown
+	keyCtrlArrowLeft
+	keyCtrlArrowRight
+	keyCtrlDelete
+	keyCtrlHome
+	keyCtrlEnd
+	keyShiftTab
+	keyAltArrowLeft
+	keyAltArrowRight
 )
 
+const keyAltBase key = 2000
+
 const (
 	hlNormal uint8 = iota
 	hlComment
@@ -107,6 +169,7 @@ const (
 	hlNumber
 	hlBoolean
 	hlMatch
+	hlSearchAll
 )
 
 func enableRawMode() (*unix.Termios, error) {
@@ -133,6 +196,7 @@ func (e *Editor) Init() error {
 	}
 
 	e.Term = termios
+	os.Stdout.WriteString(e.CursorShapeSequence())
 	ws, err := unix.IoctlGetWinsize(stdoutfd, unix.TIOCGWINSZ)
 	if err != nil || ws.Col == 0 {
 		if _, err = os.Stdout.Write([]byte("\x1b[999C\x1b[999B")); err != nil {
@@ -155,13 +219,30 @@ func (e *Editor) Close() error {
 		return fmt.Errorf("raw mode is not enabled")
 	}
 
+	os.Stdout.WriteString("\x1b[0 q")
 	return unix.IoctlSetTermios(stdinfd, ioctlWriteTermios, e.Term)
 }
 
+func (e *Editor) CursorShapeSequence() string {
+	switch e.Config.CursorShape {
+	case "block":
+		return "\x1b[2 q"
+	case "underline":
+		return "\x1b[4 q"
+	case "bar":
+		return "\x1b[6 q"
+	}
+	return "\x1b[0 q"
+}
+
 func ctrl(char byte) byte {
 	return char & 0x1f
 }
 
+func alt(char byte) key {
+	return keyAltBase + key(char)
+}
+
 func die(err error) {
 	os.Stdout.WriteString("\x1b[2J")
 	os.Stdout.WriteString("\x1b[H")
@@ -170,14 +251,14 @@ func die(err error) {
 }
 
 func ReadKey() (key, error) {
-	buf := make([]byte, 4)
+	buf := make([]byte, 8)
 	for {
 		n, err := os.Stdin.Read(buf)
 		if err != nil && err != io.EOF {
 			return 0, err
 		}
 		if n > 0 {
-			buf = bytes.TrimRightFunc(buf, func(r rune) bool { return r == 0 })
+			buf = buf[:n]
 			switch {
 			case bytes.Equal(buf, []byte("\x1b[A")):
 				return keyArrowUp, nil
@@ -187,18 +268,40 @@ func ReadKey() (key, error) {
 				return keyArrowRight, nil
 			case bytes.Equal(buf, []byte("\x1b[D")):
 				return keyArrowLeft, nil
+			case bytes.Equal(buf, []byte("\x1b[1;3A")), bytes.Equal(buf, []byte("\x1b\x1b[A")):
+				return keyAltArrowUp, nil
+			case bytes.Equal(buf, []byte("\x1b[1;3B")), bytes.Equal(buf, []byte("\x1b\x1b[B")):
+				return keyAltArrowDown, nil
+			case bytes.Equal(buf, []byte("\x1b[1;3C")), bytes.Equal(buf, []byte("\x1b\x1b[C")):
+				return keyAltArrowRight, nil
+			case bytes.Equal(buf, []byte("\x1b[1;3D")), bytes.Equal(buf, []byte("\x1b\x1b[D")):
+				return keyAltArrowLeft, nil
+			case bytes.Equal(buf, []byte("\x1b[1;5C")):
+				return keyCtrlArrowRight, nil
+			case bytes.Equal(buf, []byte("\x1b[1;5D")):
+				return keyCtrlArrowLeft, nil
+			case bytes.Equal(buf, []byte("\x1b[Z")):
+				return keyShiftTab, nil
+			case bytes.Equal(buf, []byte("\x1b[1;5H")):
+				return keyCtrlHome, nil
+			case bytes.Equal(buf, []byte("\x1b[1;5F")):
+				return keyCtrlEnd, nil
 			case bytes.Equal(buf, []byte("\x1b[1~")), bytes.Equal(buf, []byte("\x1b[7~")),
 				bytes.Equal(buf, []byte("\x1b[H")), bytes.Equal(buf, []byte("\x1bOH")):
 				return keyHome, nil
 			case bytes.Equal(buf, []byte("\x1b[4~")), bytes.Equal(buf, []byte("\x1b[8~")),
 				bytes.Equal(buf, []byte("\x1b[F")), bytes.Equal(buf, []byte("\x1bOF")):
 				return keyEnd, nil
+			case bytes.Equal(buf, []byte("\x1b[3;5~")):
+				return keyCtrlDelete, nil
 			case bytes.Equal(buf, []byte("\x1b[3~")):
 				return keyDelete, nil
 			case bytes.Equal(buf, []byte("\x1b[5~")):
 				return keyPageUp, nil
 			case bytes.Equal(buf, []byte("\x1b[6~")):
 				return keyPageDown, nil
+			case n == 2 && buf[0] == '\x1b' && buf[1] != '[' && buf[1] != 'O':
+				return alt(buf[1]), nil
 			default:
 				return key(buf[0]), nil
 			}
@@ -207,13 +310,22 @@ func ReadKey() (key, error) {
 }
 
 func (e *Editor) MoveCursor(k key) {
+	vertical := (k == keyArrowUp || k == keyArrowDown) && e.Config.StickyColOffset && !e.Config.WordWrap
+	if vertical && e.pageRX == -1 && e.CY < len(e.Rows) {
+		e.pageRX = e.RowCxToRx(e.Rows[e.CY], e.CX)
+	}
+
 	switch k {
 	case keyArrowUp:
-		if e.CY != 0 {
+		if e.Config.WordWrap {
+			e.MoveVisual(-1)
+		} else if e.CY != 0 {
 			e.CY--
 		}
 	case keyArrowDown:
-		if e.CY < len(e.Rows) {
+		if e.Config.WordWrap {
+			e.MoveVisual(1)
+		} else if e.CY < len(e.Rows) {
 			e.CY++
 		}
 	case keyArrowLeft:
@@ -236,6 +348,21 @@ func (e *Editor) MoveCursor(k key) {
 		}
 	}
 
+	switch {
+	case !e.IsHidden(e.CY):
+	case k == keyArrowUp:
+		e.skipFolded(-1)
+	case k == keyArrowLeft:
+		e.skipFolded(-1)
+		e.CX = len(e.Rows[e.CY].chars)
+	default:
+		e.skipFolded(1)
+	}
+
+	if vertical && e.pageRX != -1 && e.CY < len(e.Rows) {
+		e.CX = e.RowRxToCx(e.Rows[e.CY], e.pageRX)
+	}
+
 	var linelen int
 	if e.CY < len(e.Rows) {
 		linelen = len(e.Rows[e.CY].chars)
@@ -246,47 +373,98 @@ func (e *Editor) MoveCursor(k key) {
 	}
 }
 
-func (e *Editor) Paste() {
-	data := clipboard.Read(clipboard.FmtText)
-	dataStr := string(data)
-	if dataStr == "" {
-		return
+func (e *Editor) ProcessKey() error {
+	k, err := e.nextKey()
+	if err != nil {
+		return err
 	}
 
-	lines := strings.Split(dataStr, "\n")
-	for _, line := range lines {
-		if line != "" {
-			e.InsertRow(e.CY, line)
-			e.CY++
-			e.Dirty += len(line)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	Debugf("key %d", k)
+	e.MarkInput()
+
+	e.BeginUndoStep(k)
+	defer e.EndUndoStep()
+
+	action := e.Action(k)
+	if action == "command-palette" {
+		chosen, err := e.CommandPalette()
+		if err != nil {
+			if err == ErrPromptCanceled {
+				return nil
+			}
+			return err
 		}
+		action = chosen
 	}
-}
 
-func (e *Editor) ProcessKey() error {
-	k, err := ReadKey()
-	if err != nil {
-		return err
+	if e.Selecting && !isSelectionAction(action) {
+		defer e.ClearSelection()
 	}
-	switch k {
-	case keyEnter:
+
+	switch action {
+	case "page-up", "page-down":
+	case "up", "down":
+		if !e.Config.StickyColOffset {
+			e.pageRX = -1
+		}
+	default:
+		e.pageRX = -1
+	}
+
+	if e.hex != nil && e.HexKey(action, k) {
+		e.QuitCounter = 0
+		return nil
+	}
+
+	if len(e.cursors) > 0 {
+		if !e.Selecting && isMultiCursorAction(action, k) {
+			e.ApplyAtCursors(func() { e.EditAtCursor(action, k) })
+			e.QuitCounter = 0
+			return nil
+		}
+		defer func(dirty int) {
+			if e.Dirty != dirty {
+				e.CollapseCursors()
+			}
+		}(e.Dirty)
+	}
+
+	switch action {
+	case "newline":
 		e.InsertNewline()
 
-	case key(ctrl('q')):
+	case "quit":
 		if e.Dirty > 0 && e.QuitCounter < e.Config.QuitTimes {
-			e.SetStatusMessage("\x1b[33;1mWARNING\x1b[0m File has unsaved changes. Press Ctrl-Q %d more times to quit.", e.Config.QuitTimes-e.QuitCounter)
+			e.SetStatusMessage("\x1b[33;1mWARNING\x1b[0m %s has unsaved changes. Press Ctrl-Q %d more times to quit.", e.DisplayName(), e.Config.QuitTimes-e.QuitCounter)
 			e.QuitCounter++
 			return nil
 		}
+		if e.Dirty > 0 {
+			e.discarded = append(e.discarded, e.DisplayName())
+		}
+		e.RecordCursor()
+		if len(e.Buffers) > 1 {
+			e.CloseBuffer()
+			break
+		}
+		if err := e.SaveSession(); err != nil {
+			Debugf("save session failed: %v", err)
+		}
+		e.RemoveSwap()
 		os.Stdout.WriteString("\x1b[2J")
 		os.Stdout.WriteString("\x1b[H")
 		return ErrQuitEditor
 
-	case key(ctrl('s')):
+	case "save":
 		n, err := e.Save()
 		if err != nil {
 			if err == ErrPromptCanceled {
 				e.SetStatusMessage("Save aborted")
+			} else if err == ErrReadOnly {
+				e.SetStatusMessage("Buffer is read-only")
 			} else {
 				e.SetStatusMessage("Can't save! I/O error: %s", err.Error())
 			}
@@ -294,7 +472,19 @@ func (e *Editor) ProcessKey() error {
 			e.SetStatusMessage("%d bytes written to disk", n)
 		}
 
-	case key(ctrl('f')):
+	case "save-as":
+		n, err := e.SaveAs()
+		if err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("Save aborted")
+			} else {
+				e.SetStatusMessage("Can't save! I/O error: %s", err.Error())
+			}
+		} else {
+			e.SetStatusMessage("%d bytes written to %s", n, e.Filename)
+		}
+
+	case "find":
 		err := e.Find()
 		if err != nil {
 			if err == ErrPromptCanceled {
@@ -304,9 +494,27 @@ func (e *Editor) ProcessKey() error {
 			}
 		}
 
-	case key(ctrl('d')):
+	case "replace":
+		if err := e.Replace(); err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("Replace aborted")
+			} else {
+				return err
+			}
+		}
+
+	case "goto-line":
+		if err := e.GotoLine(); err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("")
+			} else {
+				return err
+			}
+		}
+
+	case "delete-line":
 		if e.CY < len(e.Rows) {
-			e.Rows = append(e.Rows[:e.CY], e.Rows[e.CY+1:]...)
+			e.DeleteRow(e.CY)
 		}
 		e.CX = 0
 
@@ -315,66 +523,294 @@ func (e *Editor) ProcessKey() error {
 			e.CX = len(e.Rows[e.CY].chars)
 		}
 
-	case key(ctrl('v')):
+	case "toggle-selection":
+		e.ToggleSelection()
+
+	case "copy":
+		if e.Selecting {
+			e.CopySelection()
+		} else {
+			e.CopyLine()
+		}
+
+	case "cut":
+		if e.Selecting {
+			e.CutSelection()
+		} else {
+			e.CutLine()
+		}
+
+	case "paste":
 		e.Paste()
 
-	case keyHome:
-		e.CX = 0
+	case "undo":
+		if !e.Undo() {
+			e.SetStatusMessage("Nothing to undo")
+		}
+
+	case "redo":
+		if !e.Redo() {
+			e.SetStatusMessage("Nothing to redo")
+		}
+
+	case "undo-history":
+		e.ShowUndoHistory()
 
-	case keyEnd:
+	case "normalize-line-endings":
+		if err := e.NormalizeLineEndings(); err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("")
+			} else {
+				return err
+			}
+		}
+
+	case "home":
+		e.MoveHome()
+
+	case "end":
 		if e.CY < len(e.Rows) {
 			e.CX = len(e.Rows[e.CY].chars)
 		}
 
-	case keyBackspace, key(ctrl('h')):
-		e.DeleteChar()
+	case "tab":
+		if e.Selecting {
+			e.IndentRows()
+		} else if !e.NextSnippetStop() && !e.ExpandSnippet() {
+			e.InsertTab()
+		}
+	case "dedent":
+		e.DedentRows()
+
+	case "backspace":
+		e.Backspace()
+
+	case "delete":
+		e.DeleteForward()
+
+	case "top":
+		e.MoveTop()
+	case "bottom":
+		e.MoveBottom()
+
+	case "page-up":
+		e.MovePage(-1)
+	case "page-down":
+		e.MovePage(1)
+
+	case "up":
+		e.MoveCursor(keyArrowUp)
+	case "down":
+		e.MoveCursor(keyArrowDown)
+	case "left":
+		e.MoveCursor(keyArrowLeft)
+	case "right":
+		e.MoveCursor(keyArrowRight)
 
-	case keyDelete:
-		if e.CY == len(e.Rows)-1 && e.CX == len(e.Rows[e.CY].chars) {
-			break
+	case "delete-word-left":
+		e.DeleteWordLeft()
+
+	case "delete-word-right":
+		e.DeleteWordRight()
+
+	case "word-left":
+		e.MoveWordLeft()
+
+	case "word-right":
+		e.MoveWordRight()
+
+	case "move-line-up":
+		e.MoveRow(e.CY, -1)
+
+	case "move-line-down":
+		e.MoveRow(e.CY, 1)
+
+	case "redraw":
+		break
+
+	case "escape":
+		e.CollapseCursors()
+		e.snippetStops = nil
+
+	case "add-cursor":
+		e.AddCursor()
+
+	case "jump-back":
+		if err := e.JumpBack(); err != nil {
+			return err
 		}
 
-		e.MoveCursor(keyArrowRight)
-		e.DeleteChar()
+	case "jump-forward":
+		if err := e.JumpForward(); err != nil {
+			return err
+		}
+
+	case "set-mark":
+		if err := e.SetMark(); err != nil {
+			return err
+		}
+
+	case "jump-to-mark":
+		if err := e.JumpToMark(); err != nil {
+			return err
+		}
 
-	case keyPageUp:
-		e.CY = e.RowOffset
-		for i := 0; i < e.ScreenRows; i++ {
-			e.MoveCursor(keyArrowUp)
+	case "duplicate-line":
+		e.DuplicateRow()
+
+	case "open":
+		if err := e.OpenBuffer(); err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("")
+			} else {
+				return err
+			}
+		}
+
+	case "open-recent":
+		if err := e.OpenRecent(); err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("")
+			} else {
+				return err
+			}
+		}
+
+	case "next-buffer":
+		e.SwitchBuffer(1)
+
+	case "previous-buffer":
+		e.SwitchBuffer(-1)
+
+	case "buffer-1", "buffer-2", "buffer-3", "buffer-4", "buffer-5", "buffer-6", "buffer-7", "buffer-8", "buffer-9":
+		e.SelectBuffer(int(action[len(action)-1] - '1'))
+
+	case "fold":
+		e.ToggleFold()
+
+	case "bookmark":
+		e.ToggleBookmark()
+
+	case "complete":
+		if err := e.CompleteWord(); err != nil {
+			return err
 		}
-	case keyPageDown:
-		e.CY = e.RowOffset + e.ScreenRows - 1
-		if e.CY > len(e.Rows) {
-			e.CY = len(e.Rows)
+
+	case "next-bookmark":
+		e.NextBookmark(1)
+
+	case "previous-bookmark":
+		e.NextBookmark(-1)
+
+	case "toggle-read-only":
+		e.ToggleReadOnly()
+
+	case "reload-file":
+		if err := e.ReloadFile(); err != nil {
+			e.SetStatusMessage("Can't reload: %s", err.Error())
 		}
-		for i := 0; i < e.ScreenRows; i++ {
-			e.MoveCursor(keyArrowDown)
+
+	case "reload-config":
+		if err := e.ReloadConfig(); err != nil {
+			e.SetStatusMessage("Config not reloaded: %s", err.Error())
+		} else {
+			e.SetStatusMessage("Config reloaded")
 		}
 
-	case keyArrowUp, keyArrowDown, keyArrowLeft, keyArrowRight:
-		e.MoveCursor(k)
+	case "word-count":
+		e.ShowStats()
 
-	case key(ctrl('l')), key('\x1b'):
-		break
+	case "split-vertical":
+		e.Split(false)
+
+	case "split-horizontal":
+		e.Split(true)
+
+	case "close-window":
+		e.CloseWindow()
+
+	case "other-window":
+		e.OtherWindow()
+
+	case "find-file":
+		if err := e.FindFile(); err != nil {
+			e.SetStatusMessage("Can't list files: %s", err.Error())
+		}
+
+	case "browse":
+		if err := e.BrowseFiles(); err != nil {
+			e.SetStatusMessage("Can't browse: %s", err.Error())
+		}
+
+	case "hex-mode":
+		if err := e.ToggleHexMode(); err != nil {
+			e.SetStatusMessage("Can't open hex mode: %s", err.Error())
+		}
+
+	case "upper-case":
+		e.ChangeCase(upperCase)
+
+	case "lower-case":
+		e.ChangeCase(lowerCase)
+
+	case "title-case":
+		e.ChangeCase(titleCase)
+
+	case "transpose":
+		e.Transpose()
+
+	case "join-lines":
+		e.JoinLines()
+
+	case "insert-date":
+		e.InsertDate()
+
+	case "convert-indentation":
+		if err := e.ConvertIndentation(); err != nil {
+			if err == ErrPromptCanceled {
+				e.SetStatusMessage("")
+			} else {
+				return err
+			}
+		}
 
 	default:
-		e.InsertChar(rune(k))
+		if action == "" && k < keyArrowLeft {
+			e.InsertChar(rune(k))
+		}
 	}
 
+	e.RevealCursor()
+	e.DropSnippetStops()
 	e.QuitCounter = 0
 	return nil
 }
 
 func (e *Editor) DrawRows(b *strings.Builder) {
-	for y := 0; y < e.ScreenRows; y++ {
-		filerow := y + e.RowOffset
+	if e.browser != nil {
+		e.DrawBrowserRows(b)
+		return
+	}
+	if e.hex != nil {
+		e.DrawHexRows(b)
+		return
+	}
+
+	cols := e.TextCols()
+	filerow, seg := e.RowOffset, 0
+	for y := 0; y < e.TextRows(); y++ {
+		for seg == 0 && e.IsHidden(filerow) {
+			filerow++
+		}
+		drawn := filerow
 		if filerow >= len(e.Rows) {
-			if len(e.Rows) == 0 && y == e.ScreenRows/3 {
+			e.DrawGutter(b, filerow)
+			if len(e.Rows) == 0 && y == e.TextRows()/3 {
 				welcomeMsg := fmt.Sprintf("Cookie Text Editor - Version %s", version)
-				if runewidth.StringWidth(welcomeMsg) > e.ScreenCols {
-					welcomeMsg = UTF8Slice(welcomeMsg, 0, e.ScreenCols)
+				if runewidth.StringWidth(welcomeMsg) > cols {
+					welcomeMsg = UTF8Slice(welcomeMsg, 0, cols)
 				}
-				padding := (e.ScreenCols - runewidth.StringWidth(welcomeMsg)) / 2
+				padding := (cols - runewidth.StringWidth(welcomeMsg)) / 2
 				if padding > 0 {
 					b.Write([]byte(e.Config.EmptyLineChar))
 					padding--
@@ -386,94 +822,317 @@ func (e *Editor) DrawRows(b *strings.Builder) {
 			} else {
 				b.Write([]byte(e.Config.EmptyLineChar))
 			}
+			filerow++
 
-		} else {
-			var (
-				line string
-				hl   []uint8
-			)
-			if runewidth.StringWidth(e.Rows[filerow].render) > e.ColOffset {
-				line = UTF8Slice(
-					e.Rows[filerow].render,
-					e.ColOffset,
-					utf8.RuneCountInString(e.Rows[filerow].render))
-				hl = e.Rows[filerow].hl[e.ColOffset:]
+		} else if e.Config.WordWrap {
+			row := e.Rows[filerow]
+			segs := e.WrapSegments(row)
+			if seg == 0 {
+				e.DrawGutter(b, filerow)
+			} else {
+				e.DrawGutter(b, -1)
 			}
-			if runewidth.StringWidth(line) > e.ScreenCols {
-				line = runewidth.Truncate(line, e.ScreenCols, "")
-				hl = hl[:utf8.RuneCountInString(line)]
+
+			start, end := segs[seg], len(row.hl)
+			if seg+1 < len(segs) {
+				end = segs[seg+1]
 			}
-			currentColor := -1
-			for i, r := range []rune(line) {
-				if unicode.IsControl(r) {
+			e.DrawLine(b, row, UTF8Slice(row.render, start, end), row.hl[start:end], start)
 
-					sym := '?'
-					if r < 26 {
-						sym = '@' + r
-					}
-					b.WriteString("\x1b[7m")
-					b.WriteRune(sym)
-					b.WriteString("\x1b[m")
-					if currentColor != -1 {
-						b.WriteString(fmt.Sprintf("\x1b[%dm", currentColor))
-					}
-				} else if hl[i] == hlNormal {
-					if currentColor != -1 {
-						currentColor = -1
-						b.WriteString("\x1b[39m")
-					}
-					b.WriteRune(r)
-				} else {
-					color := e.SyntaxToColor(hl[i])
-					if color != currentColor {
-						currentColor = color
-						b.WriteString(fmt.Sprintf("\u001b[38;5;%dm", color))
-					}
-					b.WriteRune(r)
-				}
+			seg++
+			if seg == len(segs) {
+				seg = 0
+				filerow++
 			}
-			b.WriteString("\x1b[39m")
+
+		} else {
+			row := e.Rows[filerow]
+			e.DrawGutter(b, filerow)
+			line, hl, start := e.SliceRender(row, e.ColOffset)
+			if runewidth.StringWidth(line) > cols {
+				line, hl = e.TruncateLine(line, hl, cols)
+			}
+			e.DrawLine(b, row, line, hl, start)
+			filerow++
 		}
 		b.Write([]byte("\x1b[K"))
+		if drawn == e.CY && e.Config.HighlightCurrentLine {
+			b.Write([]byte("\x1b[49m"))
+		}
 		b.Write([]byte("\r\n"))
 	}
 }
 
+func (e *Editor) DrawLine(b *strings.Builder, row *Row, line string, hl []uint8, start int) {
+	background := ""
+	if e.Config.HighlightCurrentLine && row.idx == e.CY {
+		background = e.Bg(e.Theme.CurrentLine)
+		b.WriteString(background)
+	}
+
+	indentEnd := 0
+	if row.mixedIndent {
+		indentEnd = e.RowCxToRenderIdx(row, len(LeadingWhitespace(row.chars)))
+	}
+	trailingStart := len(row.hl)
+	if e.Config.HighlightTrailingWhitespace && row.idx != e.CY {
+		end := len(row.chars)
+		for end > 0 && (row.chars[end-1] == ' ' || row.chars[end-1] == '\t') {
+			end--
+		}
+		trailingStart = e.RowCxToRenderIdx(row, end)
+	}
+	cellBg := ""
+
+	var markers []rune
+	if e.Config.ShowWhitespace {
+		markers = e.WhitespaceMarkers(row)
+	}
+
+	left := e.ColOffset
+	if e.Config.WordWrap {
+		left = runewidth.StringWidth(UTF8Slice(row.render, 0, start))
+	}
+	restoreBg := func() {
+		if cellBg != "" {
+			b.WriteString(cellBg)
+		} else {
+			b.WriteString("\x1b[49m" + background)
+		}
+	}
+
+	selStart, selEnd, hasSel := e.RowSelection(row)
+	cursorCells := e.CursorCells(row)
+	selected := false
+	currentColor := ""
+	col := 0
+	for i, r := range []rune(line) {
+		bg := ""
+		if i+start < indentEnd {
+			bg = e.Bg(e.Theme.MixedIndent)
+		} else if i+start >= trailingStart {
+			bg = "\x1b[41m"
+		}
+		if bg != cellBg {
+			cellBg = bg
+			restoreBg()
+		}
+
+		width := runewidth.RuneWidth(r)
+		if unicode.IsControl(r) {
+			width = 1
+		}
+		ruler := e.IsColorColumn(left+col, width)
+		if ruler {
+			b.WriteString(e.Bg(e.Theme.ColorColumn))
+		}
+		col += width
+
+		inSel := hasSel && i+start >= selStart && i+start < selEnd || cursorCells[i+start]
+		if inSel != selected {
+			selected = inSel
+			if selected {
+				b.WriteString("\x1b[7m")
+			} else {
+				b.WriteString("\x1b[27m")
+			}
+		}
+
+		if idx := i + start; idx >= 0 && idx < len(markers) && markers[idx] != 0 {
+			b.WriteString("\x1b[2m")
+			b.WriteRune(markers[idx])
+			b.WriteString("\x1b[22m")
+		} else if unicode.IsControl(r) {
+
+			sym := '?'
+			if r < 26 {
+				sym = '@' + r
+			}
+			b.WriteString("\x1b[7m")
+			b.WriteRune(sym)
+			b.WriteString("\x1b[m")
+			b.WriteString(background)
+			b.WriteString(currentColor)
+			if selected {
+				b.WriteString("\x1b[7m")
+			}
+		} else if hl[i] == hlNormal {
+			if currentColor != "" {
+				currentColor = ""
+				b.WriteString("\x1b[39m")
+			}
+			b.WriteRune(r)
+		} else {
+			color := e.Fg(e.SyntaxToColor(hl[i]))
+			if color != currentColor {
+				currentColor = color
+				b.WriteString(color)
+			}
+			b.WriteRune(r)
+		}
+
+		if ruler {
+			restoreBg()
+		}
+	}
+	if selected {
+		b.WriteString("\x1b[27m")
+	}
+	b.WriteString("\x1b[39m")
+
+	end := start + len([]rune(line))
+	if end == len(row.hl) && cursorCells[end] && col < e.TextCols() {
+		b.WriteString("\x1b[7m \x1b[27m")
+		col++
+	}
+	if end == len(row.hl) && row.fold > 0 && col < e.TextCols() {
+		summary := e.FoldSummary(row)
+		if runewidth.StringWidth(summary) > e.TextCols()-col {
+			summary = UTF8Slice(summary, 0, e.TextCols()-col)
+		}
+		b.WriteString("\x1b[2m" + summary + "\x1b[22m")
+		col += runewidth.StringWidth(summary)
+	}
+
+	if cellBg != "" {
+		cellBg = ""
+		restoreBg()
+	}
+	for last := e.LastColorColumn(left + e.TextCols()); left+col <= last; col++ {
+		if e.IsColorColumn(left+col, 1) {
+			b.WriteString(e.Bg(e.Theme.ColorColumn) + " ")
+			restoreBg()
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+}
+
 func (e *Editor) DrawStatusBar(b *strings.Builder) {
 	b.Write([]byte("\x1b[7m"))
-	defer b.Write([]byte("\x1b[m"))
-	filename := e.Filename
-	if utf8.RuneCountInString(filename) == 0 {
-		filename = "[No Name]"
+	if e.curWin != e.winIdx {
+		b.Write([]byte("\x1b[2m"))
 	}
+	cols := e.WindowCols()
+	filename := e.DisplayName()
 	dirtyStatus := ""
 	if e.Dirty > 0 {
 		dirtyStatus = "(modified)"
 	}
+	if e.ReadOnly {
+		dirtyStatus += "[RO]"
+	}
 	lmsg := fmt.Sprintf("%.35s - %d lines %s", filename, len(e.Rows), dirtyStatus)
-	if runewidth.StringWidth(lmsg) > e.ScreenCols {
-		lmsg = runewidth.Truncate(lmsg, e.ScreenCols, "...")
+	if e.hex != nil {
+		lmsg = fmt.Sprintf("%.35s - %d bytes %s", filename, len(e.hex.data), dirtyStatus)
+	}
+	if len(e.Buffers) > 1 {
+		lmsg = fmt.Sprintf("[%d/%d] %s", e.BufferIndex()+1, len(e.Buffers), lmsg)
+	}
+	if runewidth.StringWidth(lmsg) > cols {
+		lmsg = runewidth.Truncate(lmsg, cols, "...")
 	}
 	b.WriteString(lmsg)
 	filetype := "no filetype"
 	if e.Syntax != nil {
 		filetype = e.Syntax.FileType
 	}
-	rmsg := fmt.Sprintf("%s | %d/%d", filetype, e.CY+1, len(e.Rows))
+	ending := lineEndingName(e.EffectiveLineEnding())
+	if e.HasBOM {
+		ending += " BOM"
+	}
+	rmsg := fmt.Sprintf("%s | %s | %d/%d", filetype, ending, e.CY+1, len(e.Rows))
+	if e.hex != nil {
+		rmsg = fmt.Sprintf("hex | 0x%08x/%d", e.hex.cursor, len(e.hex.data))
+	}
+	if e.gitBranch != "" {
+		rmsg = e.gitBranch + " | " + rmsg
+	}
+	if e.Config.ShowColumn {
+		rmsg += fmt.Sprintf(" col %d", e.RX+1)
+	}
+	if e.Config.ShowPercentage {
+		rmsg += " | " + e.ScrollPercentage()
+	}
 	l := runewidth.StringWidth(lmsg)
-	for l < e.ScreenCols {
-		if e.ScreenCols-l == runewidth.StringWidth(rmsg) {
+	for l < cols {
+		if cols-l == runewidth.StringWidth(rmsg) {
 			b.WriteString(rmsg)
 			break
 		}
 		b.Write([]byte(" "))
 		l++
 	}
-	b.Write([]byte("\r\n"))
+	b.Write([]byte("\x1b[m\r\n"))
+}
+
+func (e *Editor) ScrollPercentage() string {
+	switch {
+	case len(e.Rows) <= 1:
+		return "All"
+	case e.CY == 0:
+		return "Top"
+	case e.CY >= len(e.Rows)-1:
+		return "Bot"
+	}
+	return fmt.Sprintf("%d%%", e.CY*100/len(e.Rows))
+}
+
+func (e *Editor) TruncateLine(line string, hl []uint8, width int) (string, []uint8) {
+	truncated := runewidth.Truncate(line, width, "")
+	n := utf8.RuneCountInString(truncated)
+	hl = hl[:n:n]
+
+	gap := width - runewidth.StringWidth(truncated)
+	if gap <= 0 {
+		return truncated, hl
+	}
+
+	fill := " "
+	if e.Config.WideCharBoundary == "marker" {
+		fill = ">"
+	}
+	truncated += strings.Repeat(fill, gap)
+	for i := 0; i < gap; i++ {
+		hl = append(hl, hlNormal)
+	}
+	return truncated, hl
+}
+
+func (e *Editor) SliceRender(row *Row, col int) (string, []uint8, int) {
+	runes := []rune(row.render)
+	idx, width := 0, 0
+	for idx < len(runes) && width < col {
+		width += runewidth.RuneWidth(runes[idx])
+		idx++
+	}
+	if width < col {
+		return "", nil, idx
+	}
+
+	pad := width - col
+	fill := " "
+	if e.Config.WideCharBoundary == "marker" {
+		fill = "<"
+	}
+
+	hl := make([]uint8, pad, pad+len(row.hl)-idx)
+	hl = append(hl, row.hl[idx:]...)
+	return strings.Repeat(fill, pad) + UTF8Slice(row.render, idx, len(runes)), hl, idx - pad
 }
 
 func UTF8Slice(s string, start, end int) string {
-	return string([]rune(s)[start:end])
+	runes := []rune(s)
+	if start < 0 {
+		start = 0
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+	if start >= end {
+		return ""
+	}
+	return string(runes[start:end])
 }
 
 func (e *Editor) DrawMessageBar(b *strings.Builder) {
@@ -492,7 +1151,7 @@ func (e *Editor) RowCxToRx(row *Row, cx int) int {
 	rx := 0
 	for _, r := range row.chars[:cx] {
 		if r == '\t' {
-			rx += (e.Config.TabStop) - (rx % e.Config.TabStop)
+			rx += e.TabStop() - (rx % e.TabStop())
 		} else {
 			rx += runewidth.RuneWidth(r)
 		}
@@ -504,7 +1163,7 @@ func (e *Editor) RowRxToCx(row *Row, rx int) int {
 	curRx := 0
 	for i, r := range row.chars {
 		if r == '\t' {
-			curRx += (e.Config.TabStop) - (curRx % e.Config.TabStop)
+			curRx += e.TabStop() - (curRx % e.TabStop())
 		} else {
 			curRx += runewidth.RuneWidth(r)
 		}
@@ -513,48 +1172,171 @@ func (e *Editor) RowRxToCx(row *Row, rx int) int {
 			return i
 		}
 	}
-	panic("unreachable")
+	return len(row.chars)
 }
 
 func (e *Editor) Scroll() {
+	e.LoadRows()
+	if e.hex != nil {
+		e.ScrollHex()
+		return
+	}
+
 	e.RX = 0
 	if e.CY < len(e.Rows) {
 		e.RX = e.RowCxToRx(e.Rows[e.CY], e.CX)
 	}
 
-	if e.CY < e.RowOffset {
-		e.RowOffset = e.CY
+	if e.Config.WordWrap {
+		e.ScrollWrapped()
+		return
+	}
+
+	if e.folds > 0 {
+		e.scrollVisible(0)
+	} else {
+		e.scrollRows()
 	}
 
-	if e.CY >= e.RowOffset+e.ScreenRows {
-		e.RowOffset = e.CY - e.ScreenRows + 1
+	cols := e.TextCols()
+	margin := 0
+	if e.Config.StickyColOffset {
+		margin = e.Config.ColOffsetMargin
+		if margin > cols/2 {
+			margin = cols / 2
+		}
+		if margin < 0 {
+			margin = 0
+		}
+
+		// While moving up and down, go back to the view the moves started
+		// from whenever the cursor fits in it, so a short line only shifts
+		// the view until the cursor is past it.
+		if e.pageRX == -1 {
+			e.stickyCol = -1
+		} else if e.stickyCol == -1 {
+			e.stickyCol = e.ColOffset
+		} else if e.RX >= e.stickyCol && e.RX < e.stickyCol+cols {
+			e.ColOffset = e.stickyCol
+		}
 	}
 
 	if e.RX < e.ColOffset {
-		e.ColOffset = e.RX
+		e.ColOffset = e.RX - margin
+		if e.ColOffset < 0 {
+			e.ColOffset = 0
+		}
+	}
+
+	if e.RX >= e.ColOffset+cols {
+		e.ColOffset = e.RX - cols + 1 + margin
+	}
+}
+
+func (e *Editor) scrollRows() {
+	off := e.Config.ScrollOff
+	if off > (e.TextRows()-1)/2 {
+		off = (e.TextRows() - 1) / 2
+	}
+	if off < 0 {
+		off = 0
+	}
+
+	if e.CY < e.RowOffset+off {
+		e.RowOffset = e.CY - off
+		if e.RowOffset < 0 {
+			e.RowOffset = 0
+		}
+	}
+
+	if e.CY >= e.RowOffset+e.TextRows()-off {
+		e.RowOffset = e.CY - e.TextRows() + 1 + off
+		if last := len(e.Rows) + 1 - e.TextRows(); e.RowOffset > last {
+			e.RowOffset = last
+		}
+		if e.RowOffset < e.CY-e.TextRows()+1 {
+			e.RowOffset = e.CY - e.TextRows() + 1
+		}
+		if e.RowOffset < 0 {
+			e.RowOffset = 0
+		}
+	}
+}
+
+func (e *Editor) Render() {
+	e.Scroll()
+	Debugf("render cx=%d cy=%d rows=%d screen=%dx%d", e.CX, e.CY, len(e.Rows), e.ScreenCols, e.ScreenRows)
+
+	var frame strings.Builder
+
+	e.DrawTabBar(&frame)
+	e.DrawWindows(&frame)
+	e.DrawMessageBar(&frame)
+
+	lines := strings.Split(frame.String(), "\r\n")
+	full := len(lines) != len(e.prevFrame)
+
+	var b strings.Builder
+	if full {
+		b.Write([]byte("\x1b[?25l"))
+		b.Write([]byte("\x1b[2J"))
+		b.WriteString(e.CursorShapeSequence())
+	}
+	for i, line := range lines {
+		if !full && line == e.prevFrame[i] {
+			continue
+		}
+		if b.Len() == 0 {
+			b.Write([]byte("\x1b[?25l"))
+		}
+		b.WriteString(fmt.Sprintf("\x1b[%d;1H", i+1))
+		b.WriteString(line)
+	}
+	changed := b.Len() > 0
+	e.prevFrame = lines
+
+	cy, cx := e.CY-e.RowOffset, e.RX-e.ColOffset
+	if e.browser != nil {
+		cy, cx = e.browser.sel-e.browser.offset, 0
+	} else if e.hex != nil {
+		cy, cx = e.HexCursor()
+	} else if e.Config.WordWrap {
+		cy, cx = e.WrappedCursor()
+	} else if e.folds > 0 {
+		cy = e.screenRowsBetween(e.RowOffset, e.CY)
+	}
+	if e.isSplit() {
+		x, y, _, _ := e.windowRect(e.winIdx)
+		cy, cx = cy+y, cx+x
+	}
+	if len(e.popup) > 0 && e.popupBottom {
+		e.DrawPopup(&b, e.ScreenRows, 0)
+		changed = true
+	} else if len(e.popup) > 0 {
+		e.DrawPopup(&b, cy+e.TabBarHeight(), cx+e.GutterWidth())
+		changed = true
 	}
+	b.WriteString(fmt.Sprintf("\x1b[%d;%dH", cy+e.TabBarHeight()+1, cx+e.GutterWidth()+1))
 
-	if e.RX >= e.ColOffset+e.ScreenCols {
-		e.ColOffset = e.RX - e.ScreenCols + 1
+	if changed {
+		b.Write([]byte("\x1b[?25h"))
 	}
+	os.Stdout.WriteString(b.String())
 }
 
-func (e *Editor) Render() {
-	e.Scroll()
-
-	var b strings.Builder
-
-	b.Write([]byte("\x1b[?25l"))
-	b.Write([]byte("\x1b[H"))
-
-	e.DrawRows(&b)
-	e.DrawStatusBar(&b)
-	e.DrawMessageBar(&b)
+func (e *Editor) InvalidateScreen() {
+	e.prevFrame = nil
+}
 
-	b.WriteString(fmt.Sprintf("\x1b[%d;%dH", (e.CY-e.RowOffset)+1, (e.RX-e.ColOffset)+1))
+func (e *Editor) Resize(cols, rows int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	b.Write([]byte("\x1b[?25h"))
-	os.Stdout.WriteString(b.String())
+	e.ScreenCols = cols
+	e.ScreenRows = rows
+	e.InvalidateScreen()
+	Debugf("resize %dx%d", e.ScreenCols, e.ScreenRows)
+	e.Render()
 }
 
 func (e *Editor) SetStatusMessage(format string, a ...interface{}) {
@@ -573,23 +1355,87 @@ func getCursorPosition() (row, col int, err error) {
 }
 
 func (e *Editor) RowsToString() string {
+	ending := e.EffectiveLineEnding()
+
 	var b strings.Builder
-	for _, row := range e.Rows {
-		b.WriteString(string(row.chars))
-		b.WriteRune('\n')
+	for i := range e.Rows {
+		b.WriteString(string(e.RowAt(i).chars))
+		if i < len(e.Rows)-1 || !e.NoFinalNewline || e.Config.EnsureFinalNewline {
+			b.WriteString(ending)
+		}
 	}
 	return b.String()
 }
 
 var ErrPromptCanceled = fmt.Errorf("user canceled the input prompt")
 
+func (e *Editor) WaitKey() (key, error) {
+	e.prompting = true
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.prompting = false
+	}()
+
+	return e.nextKey()
+}
+
+func (e *Editor) nextKey() (key, error) {
+	if len(e.pendingKeys) > 0 {
+		k := e.pendingKeys[0]
+		e.pendingKeys = e.pendingKeys[1:]
+		return k, nil
+	}
+	return ReadKey()
+}
+
+// UnreadKey queues k to be returned by the next key read, ahead of
+// anything typed at the terminal.
+func (e *Editor) UnreadKey(k key) {
+	e.pendingKeys = append(e.pendingKeys, k)
+}
+
+func (e *Editor) Confirm(format string, a ...interface{}) (bool, error) {
+	e.SetStatusMessage(format+" (y/n)", a...)
+	e.Render()
+
+	k, err := e.WaitKey()
+	if err != nil {
+		return false, err
+	}
+	e.SetStatusMessage("")
+	return k == key('y') || k == key('Y'), nil
+}
+
 func (e *Editor) Prompt(prompt string, cb func(query string, k key)) (string, error) {
+	return e.PromptLabel(func() string { return prompt }, cb)
+}
+
+func (e *Editor) PromptLabel(label func() string, cb func(query string, k key)) (string, error) {
+	return e.PromptEdit(label, func(query string, k key) string {
+		if cb != nil {
+			cb(query, k)
+		}
+		return query
+	})
+}
+
+func (e *Editor) PromptEdit(label func() string, cb func(query string, k key) string) (string, error) {
+	return e.promptEdit(label, cb, false)
+}
+
+// PromptOptional is like Prompt, but Enter also accepts an empty answer.
+func (e *Editor) PromptOptional(prompt string) (string, error) {
+	return e.promptEdit(func() string { return prompt }, func(query string, k key) string { return query }, true)
+}
+
+func (e *Editor) promptEdit(label func() string, cb func(query string, k key) string, allowEmpty bool) (string, error) {
 	var b strings.Builder
 	for {
-		e.SetStatusMessage(prompt, b.String())
+		e.SetStatusMessage(label(), b.String())
 		e.Render()
 
-		k, err := ReadKey()
+		k, err := e.WaitKey()
 		if err != nil {
 			return "", err
 		}
@@ -607,19 +1453,21 @@ func (e *Editor) Prompt(prompt string, cb func(query string, k key)) (string, er
 			}
 			return "", ErrPromptCanceled
 		} else if k == keyEnter {
-			if b.Len() > 0 {
+			if b.Len() > 0 || allowEmpty {
 				e.SetStatusMessage("")
 				if cb != nil {
 					cb(b.String(), k)
 				}
 				return b.String(), nil
 			}
-		} else if !unicode.IsControl(rune(k)) && !isArrowKey(k) && unicode.IsPrint(rune(k)) {
+		} else if !unicode.IsControl(rune(k)) && !isArrowKey(k) && !isAltKey(k) && unicode.IsPrint(rune(k)) {
 			b.WriteRune(rune(k))
 		}
 
 		if cb != nil {
-			cb(b.String(), k)
+			query := cb(b.String(), k)
+			b.Reset()
+			b.WriteString(query)
 		}
 	}
 }
@@ -628,9 +1476,16 @@ func isArrowKey(k key) bool {
 	return k == keyArrowUp || k == keyArrowRight || k == keyArrowDown || k == keyArrowLeft
 }
 
+func isAltKey(k key) bool {
+	return k > keyAltBase && k < keyAltBase+utf8.RuneSelf
+}
+
 func (e *Editor) Save() (int, error) {
+	if e.ReadOnly {
+		return 0, ErrReadOnly
+	}
 	if len(e.Filename) == 0 {
-		fname, err := e.Prompt("Save as: %s (ESC to cancel)", nil)
+		fname, err := e.PromptFilename("Save as: %s (ESC to cancel | Tab = Complete)")
 		if err != nil {
 			return 0, err
 		}
@@ -638,47 +1493,225 @@ func (e *Editor) Save() (int, error) {
 		e.SelectSyntaxHighlight()
 	}
 
-	f, err := os.OpenFile(e.Filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if e.ChangedOnDisk() {
+		ok, err := e.Confirm("%s changed on disk since it was opened. Overwrite it?", e.DisplayName())
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, ErrPromptCanceled
+		}
+	}
+
+	if err := e.EnsureParentDir(e.Filename); err != nil {
+		return 0, err
+	}
+
+	n, err := e.WriteFile(e.Filename)
 	if err != nil {
 		return 0, err
 	}
 
-	defer f.Close()
-	n, err := f.WriteString(e.RowsToString())
+	e.RecordDiskState()
+	e.RefreshGitSigns()
+	e.RefreshGitBranch()
+	return n, nil
+}
+
+func (e *Editor) SaveAs() (int, error) {
+	fname, err := e.PromptFilename("Save as: %s (ESC to cancel | Tab = Complete)")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := e.EnsureParentDir(fname); err != nil {
+		return 0, err
+	}
+
+	n, err := e.WriteFile(fname)
+	if err != nil {
+		return 0, err
+	}
+
+	e.Filename = fname
+	e.SelectSyntaxHighlight()
+	e.RecordDiskState()
+	e.RefreshGitSigns()
+	e.RefreshGitBranch()
+	return n, nil
+}
+
+func (e *Editor) EnsureParentDir(filename string) error {
+	dir := filepath.Dir(filename)
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		return nil
+	}
+
+	ok, err := e.Confirm("Create directory %s?", dir)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPromptCanceled
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("permission denied creating %s", dir)
+		}
+		return err
+	}
+	return nil
+}
+
+// createTempFile creates a new, uniquely named file next to path. It never
+// opens an existing file, so a user's own files and symlinks are left alone.
+func createTempFile(path string, perm os.FileMode) (*os.File, error) {
+	dir, base := filepath.Split(path)
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, fmt.Sprintf(".%s.%d.tmp", base, time.Now().UnixNano()+int64(i)))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if !os.IsExist(err) || i == 100 {
+			return f, err
+		}
+	}
+}
+
+func (e *Editor) WriteFile(filename string) (int, error) {
+	// Write through symlinks rather than replacing the link with a file.
+	path := filename
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		path = target
+	}
+
+	perm, chmod, _ := e.Config.FileMode()
+	if info, err := os.Stat(path); err == nil {
+		perm, chmod = info.Mode().Perm(), true
+	}
+
+	f, err := createTempFile(path, perm)
+	if err != nil {
+		Debugf("save %s failed: %v", filename, err)
+		return 0, err
+	}
+	tmp := f.Name()
+
+	content := e.RowsToString()
+	if e.HasBOM && e.Config.KeepBOM {
+		content = utf8BOM + content
+	}
+	if e.hex != nil {
+		content = string(e.hex.data)
+	}
+
+	n, err := f.WriteString(content)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && chmod {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
 	if err != nil {
+		Debugf("save %s failed: %v", filename, err)
+		os.Remove(tmp)
 		return 0, err
 	}
+
+	if e.hex != nil {
+		e.hex.written = true
+	}
+	e.RemoveSwap()
 	e.Dirty = 0
+	e.MarkUndoSaved()
+	e.recordSave(filename, n)
 
 	return n, nil
 }
 
 func (e *Editor) OpenFile(filename string) error {
+	filename = ExpandPath(filename)
+	info, statErr := os.Stat(filename)
+	if statErr == nil && info.IsDir() {
+		return ErrIsDirectory
+	}
+
 	e.Filename = filename
 	e.SelectSyntaxHighlight()
+	e.CloseLargeFile()
+	if statErr == nil && e.IsLargeFile(info.Size()) {
+		if err := e.OpenLargeFile(filename); err != nil {
+			return err
+		}
+		e.RecordDiskState()
+		e.RefreshGitBranch()
+		e.AddRecentFile()
+		return nil
+	}
+
 	f, err := os.Open(filename)
 	if err != nil {
+		if os.IsNotExist(err) {
+			if err := e.RecoverSwap(); err != nil {
+				return err
+			}
+		}
 		return err
 	}
 	defer f.Close()
-	s := bufio.NewScanner(f)
+	e.RecordDiskState()
+	e.undo.suspended = true
+	defer e.ResetUndo()
+
+	r := bufio.NewReaderSize(f, binarySniffLen)
+	head, _ := r.Peek(binarySniffLen)
+	binary := looksBinary(head)
+
+	counter := &lineEndingCounter{}
+	s := bufio.NewScanner(r)
+	s.Split(counter.Split)
+	e.HasBOM = false
 	for s.Scan() {
-		line := s.Bytes()
-
-		bytes.TrimRightFunc(line, func(r rune) bool { return r == '\n' || r == '\r' })
-		e.InsertRow(len(e.Rows), string(line))
+		line := s.Text()
+		if len(e.Rows) == 0 && strings.HasPrefix(line, utf8BOM) {
+			line = strings.TrimPrefix(line, utf8BOM)
+			e.HasBOM = true
+		}
+		e.InsertRow(len(e.Rows), line)
 	}
 	if err := s.Err(); err != nil {
 		return err
 	}
+	e.LineEnding = counter.Dominant()
+	e.MixedLineEndings = counter.Mixed()
+	e.NoFinalNewline = counter.unfinished
+	if e.MixedLineEndings {
+		e.SetStatusMessage("\x1b[33;1mWARNING\x1b[0m Mixed line endings (%d LF, %d CRLF), saving as %s. Ctrl-E to normalize.", counter.lf, counter.crlf, lineEndingName(e.LineEnding))
+	}
+	e.Binary = false
+	if binary {
+		e.MarkBinary()
+	}
 	e.Dirty = 0
-	return nil
+	e.RefreshGitSigns()
+	e.RefreshGitBranch()
+	e.AddRecentFile()
+	return e.RecoverSwap()
 }
 
 func (e *Editor) InsertRow(at int, chars string) {
 	if at < 0 || at > len(e.Rows) {
 		return
 	}
+	if e.IsHidden(at) {
+		e.UnfoldAt(at)
+	}
+
 	row := &Row{chars: []rune(chars)}
 	row.idx = at
 	if at > 0 {
@@ -692,21 +1725,38 @@ func (e *Editor) InsertRow(at int, chars string) {
 		e.Rows[i].idx++
 	}
 	e.Rows[at] = row
+	e.shiftMarks(at, 1)
+	e.shiftBookmarks(at, 1)
+	e.shiftSnippetRows(at, 1)
+	e.recordEdit(editOp{kind: editInsertRow, row: at, chars: []rune(chars)})
 }
 
 func (e *Editor) InsertNewline() {
+	if !e.Writable() {
+		return
+	}
+
+	indent := ""
 	if e.CX == 0 {
 		e.InsertRow(e.CY, "")
 	} else {
 		row := e.Rows[e.CY]
-		e.InsertRow(e.CY+1, string(row.chars[e.CX:]))
-
-		row = e.Rows[e.CY]
-		row.chars = row.chars[:e.CX]
-		e.UpdateRow(row)
+		if e.Config.AutoIndent {
+			indent = LeadingWhitespace(row.chars[:e.CX])
+		}
+		e.InsertRow(e.CY+1, indent+string(row.chars[e.CX:]))
+		e.DeleteRunes(e.CY, e.CX, len(row.chars)-e.CX)
 	}
 	e.CY++
-	e.CX = 0
+	e.CX = utf8.RuneCountInString(indent)
+}
+
+func LeadingWhitespace(chars []rune) string {
+	n := 0
+	for n < len(chars) && (chars[n] == ' ' || chars[n] == '\t') {
+		n++
+	}
+	return string(chars[:n])
 }
 
 func (e *Editor) UpdateRow(row *Row) {
@@ -718,15 +1768,22 @@ func (e *Editor) UpdateRow(row *Row) {
 			b.WriteRune(' ')
 			col++
 
-			for col%e.Config.TabStop != 0 {
+			for col%e.TabStop() != 0 {
 				b.WriteRune(' ')
 				col++
 			}
 		} else {
 			b.WriteRune(r)
+			col += runewidth.RuneWidth(r)
 		}
 	}
 	row.render = b.String()
+	row.mixedIndent = false
+	if e.Config.WarnMixedIndent {
+		indent := LeadingWhitespace(row.chars)
+		row.mixedIndent = strings.ContainsRune(indent, ' ') && strings.ContainsRune(indent, '\t')
+	}
+	row.hlValid = false
 	e.UpdateHighlight(row)
 }
 
@@ -735,6 +1792,17 @@ func IsSeparator(r rune) bool {
 }
 
 func (e *Editor) UpdateHighlight(row *Row) {
+	inComment := false
+	mlString := ""
+	if row.idx > 0 && e.Rows[row.idx-1] != nil {
+		inComment = e.Rows[row.idx-1].hasUnclosedComment
+		mlString = e.Rows[row.idx-1].unclosedString
+	}
+	if row.hlValid && row.hlSyntax == e.Syntax && row.hlInComment == inComment && row.hlInString == mlString {
+		return
+	}
+	row.hlValid, row.hlSyntax, row.hlInComment, row.hlInString = true, e.Syntax, inComment, mlString
+
 	row.hl = make([]uint8, utf8.RuneCountInString(row.render))
 	for i := range row.hl {
 		row.hl[i] = hlNormal
@@ -748,8 +1816,6 @@ func (e *Editor) UpdateHighlight(row *Row) {
 
 	var strQuote rune
 
-	inComment := row.idx > 0 && e.Rows[row.idx-1].hasUnclosedComment
-
 	idx := 0
 	runes := []rune(row.render)
 	for idx < len(runes) {
@@ -759,6 +1825,26 @@ func (e *Editor) UpdateHighlight(row *Row) {
 			prevHl = row.hl[idx-1]
 		}
 
+		if mlString != "" {
+			row.hl[idx] = hlString
+			if r == '\\' && mlString != "`" && idx+1 < len(runes) {
+				row.hl[idx+1] = hlString
+				idx += 2
+				continue
+			}
+			if strings.HasPrefix(string(runes[idx:]), mlString) {
+				for j := 0; j < utf8.RuneCountInString(mlString); j++ {
+					row.hl[idx] = hlString
+					idx++
+				}
+				mlString = ""
+				prevSep = true
+				continue
+			}
+			idx++
+			continue
+		}
+
 		if e.Syntax.SCS != "" && strQuote == 0 && !inComment {
 			if strings.HasPrefix(string(runes[idx:]), e.Syntax.SCS) {
 				for idx < len(runes) {
@@ -794,6 +1880,24 @@ func (e *Editor) UpdateHighlight(row *Row) {
 			}
 		}
 
+		if e.Syntax.Flags.HighLightMultiLineStrings && strQuote == 0 {
+			opened := false
+			for _, delim := range e.Syntax.MLS {
+				if delim != "" && strings.HasPrefix(string(runes[idx:]), delim) {
+					for j := 0; j < utf8.RuneCountInString(delim); j++ {
+						row.hl[idx] = hlString
+						idx++
+					}
+					mlString = delim
+					opened = true
+					break
+				}
+			}
+			if opened {
+				continue
+			}
+		}
+
 		if e.Syntax.Flags.HighLightStrings {
 			if strQuote != 0 {
 				row.hl[idx] = hlString
@@ -820,10 +1924,10 @@ func (e *Editor) UpdateHighlight(row *Row) {
 		}
 
 		if e.Syntax.Flags.HighLightNumbers {
-			if unicode.IsDigit(r) && (prevSep || prevHl == hlNumber) ||
-				r == '.' && prevHl == hlNumber {
-				row.hl[idx] = hlNumber
-				idx++
+			if unicode.IsDigit(r) && (prevSep || prevHl == hlNumber) {
+				for end := idx + ScanNumber(runes[idx:], e.Syntax.Numbers); idx < end; idx++ {
+					row.hl[idx] = hlNumber
+				}
 				prevSep = false
 				continue
 			}
@@ -888,40 +1992,58 @@ func (e *Editor) UpdateHighlight(row *Row) {
 		idx++
 	}
 
-	changed := row.hasUnclosedComment != inComment
+	changed := row.hasUnclosedComment != inComment || row.unclosedString != mlString
 	row.hasUnclosedComment = inComment
-	if changed && row.idx+1 < len(e.Rows) {
+	row.unclosedString = mlString
+	if changed && row.idx+1 < len(e.Rows) && e.Rows[row.idx+1] != nil {
 		e.UpdateHighlight(e.Rows[row.idx+1])
 	}
 }
 
-func (e *Editor) SyntaxToColor(hl uint8) int {
+func (e *Editor) SyntaxToColor(hl uint8) Color {
 	switch hl {
 	case hlComment:
-		return int(e.Config.ColorPalette.Comment)
+		return e.Theme.Comment
 	case hlMlComment:
-		return int(e.Config.ColorPalette.MultiLineComment)
+		return e.Theme.MultiLineComment
 	case hlKeyword1:
-		return int(e.Config.ColorPalette.Keyword1)
+		return e.Theme.Keyword1
 	case hlKeyword2:
-		return int(e.Config.ColorPalette.Keyword2)
+		return e.Theme.Keyword2
 	case hlString:
-		return int(e.Config.ColorPalette.String)
+		return e.Theme.String
 	case hlNumber:
-		return int(e.Config.ColorPalette.Number)
+		return e.Theme.Number
 	case hlBoolean:
-		return int(e.Config.ColorPalette.Boolean)
+		return e.Theme.Boolean
 	case hlMatch:
-		return int(e.Config.ColorPalette.Match)
+		return e.Theme.Match
+	case hlSearchAll:
+		return e.Theme.SearchAll
 	default:
-		return int(e.Config.ColorPalette.Normal)
+		return e.Theme.Normal
 	}
 }
 
+func (e *Editor) TabStop() int {
+	if e.Syntax != nil && e.Syntax.TabStop > 0 {
+		return e.Syntax.TabStop
+	}
+	return e.Config.TabStop
+}
+
 func (e *Editor) SelectSyntaxHighlight() {
-	e.Syntax = nil
+	e.Syntax = e.matchSyntax()
+	for _, row := range e.Rows {
+		if row != nil {
+			e.UpdateRow(row)
+		}
+	}
+}
+
+func (e *Editor) matchSyntax() *EditorSyntax {
 	if len(e.Filename) == 0 {
-		return
+		return nil
 	}
 
 	ext := filepath.Ext(e.Filename)
@@ -930,44 +2052,63 @@ func (e *Editor) SelectSyntaxHighlight() {
 		for _, pattern := range syntax.FileMatch {
 			isExt := strings.HasPrefix(pattern, ".")
 			if (isExt && pattern == ext) || (!isExt && strings.Contains(e.Filename, pattern)) {
-				e.Syntax = syntax
-				for _, row := range e.Rows {
-					e.UpdateHighlight(row)
-				}
-				return
+				return syntax
 			}
 		}
 	}
+	return nil
 }
 
-func (row *Row) InsertChar(at int, c rune) {
+func (e *Editor) InsertRunes(y, at int, chars []rune) {
+	if y < 0 || y >= len(e.Rows) || len(chars) == 0 {
+		return
+	}
+
+	row := e.Rows[y]
 	if at < 0 || at > len(row.chars) {
 		at = len(row.chars)
 	}
-	row.chars = append(row.chars, 0)
-	copy(row.chars[at+1:], row.chars[at:])
-	row.chars[at] = c
-}
 
-func (row *Row) AppendChars(chars []rune) {
-	row.chars = append(row.chars, chars...)
+	updated := make([]rune, 0, len(row.chars)+len(chars))
+	updated = append(updated, row.chars[:at]...)
+	updated = append(updated, chars...)
+	updated = append(updated, row.chars[at:]...)
+	row.chars = updated
+	e.UpdateRow(row)
+	e.shiftSnippetStops(y, at, len(chars))
+	e.recordEdit(editOp{kind: editInsertRunes, row: y, at: at, chars: append([]rune(nil), chars...)})
 }
 
-func (row *Row) DeleteChar(at int) {
-	if at < 0 || at >= len(row.chars) {
+func (e *Editor) DeleteRunes(y, at, n int) {
+	if y < 0 || y >= len(e.Rows) {
 		return
 	}
-	row.chars = append(row.chars[:at], row.chars[at+1:]...)
+
+	row := e.Rows[y]
+	if at < 0 || n <= 0 || at >= len(row.chars) {
+		return
+	}
+	if at+n > len(row.chars) {
+		n = len(row.chars) - at
+	}
+
+	deleted := append([]rune(nil), row.chars[at:at+n]...)
+	row.chars = append(row.chars[:at:at], row.chars[at+n:]...)
+	e.UpdateRow(row)
+	e.shiftSnippetStops(y, at, -n)
+	e.recordEdit(editOp{kind: editDeleteRunes, row: y, at: at, chars: deleted})
 }
 
 func (e *Editor) InsertChar(c rune) {
+	if !e.Writable() {
+		return
+	}
+
 	if e.CY == len(e.Rows) {
 		e.InsertRow(len(e.Rows), "")
 	}
 
-	row := e.Rows[e.CY]
-	row.InsertChar(e.CX, c)
-	e.UpdateRow(row)
+	e.InsertRunes(e.CY, e.CX, []rune{c})
 	e.CX++
 	e.Dirty++
 
@@ -1002,10 +2143,41 @@ func (e *Editor) InsertChar(c rune) {
 
 		e.InsertChar('`')
 		e.CX--
+	case '}', ']', ')':
+		if e.Config.ElectricBraces {
+			e.ElectricDedent(c)
+		}
+	}
+}
+
+func (e *Editor) Backspace() {
+	if !e.Writable() {
+		return
+	}
+
+	if !e.DeleteIndent() {
+		e.DeleteChar()
+	}
+}
+
+func (e *Editor) DeleteForward() {
+	if !e.Writable() {
+		return
+	}
+
+	if e.CY >= len(e.Rows) || e.CY == len(e.Rows)-1 && e.CX == len(e.Rows[e.CY].chars) {
+		return
 	}
+
+	e.MoveCursor(keyArrowRight)
+	e.DeleteChar()
 }
 
 func (e *Editor) DeleteChar() {
+	if !e.Writable() {
+		return
+	}
+
 	if e.CY == len(e.Rows) {
 		return
 	}
@@ -1017,104 +2189,126 @@ func (e *Editor) DeleteChar() {
 	row := e.Rows[e.CY]
 
 	if e.CX > 0 {
-		row.DeleteChar(e.CX - 1)
-		e.UpdateRow(row)
+		e.DeleteRunes(e.CY, e.CX-1, 1)
 		e.CX--
 		e.Dirty++
 	} else {
 		prevRow := e.Rows[e.CY-1]
 		e.CX = len(prevRow.chars)
-		prevRow.AppendChars(row.chars)
-		e.UpdateRow(prevRow)
+		e.InsertRunes(e.CY-1, e.CX, row.chars)
 		e.DeleteRow(e.CY)
 		e.CY--
 	}
 }
 
-func (e *Editor) DeleteRow(at int) {
-	if at < 0 || at >= len(e.Rows) {
+func (e *Editor) MoveRow(at, delta int) {
+	if !e.Writable() {
 		return
 	}
-	e.Rows = append(e.Rows[:at], e.Rows[at+1:]...)
-	for i := at; i < len(e.Rows); i++ {
-		e.Rows[i].idx--
+
+	to := at + delta
+	if at < 0 || at >= len(e.Rows) || to < 0 || to >= len(e.Rows) {
+		return
+	}
+
+	chars := string(e.Rows[at].chars)
+	bookmarked := e.bookmarks[at]
+	e.DeleteRow(at)
+	e.InsertRow(to, chars)
+	if bookmarked {
+		e.bookmarks[to] = true
+	}
+
+	first, last := at, to
+	if first > last {
+		first, last = last, first
+	}
+	for i := first; i <= last; i++ {
+		e.UpdateHighlight(e.Rows[i])
+	}
+
+	if e.CY == at {
+		e.CY = to
 	}
-	e.Dirty++
 }
 
-func (e *Editor) Find() error {
-	savedCx := e.CX
-	savedCy := e.CY
-	savedColOffset := e.ColOffset
-	savedRowOffset := e.RowOffset
+func (e *Editor) DuplicateRow() {
+	if !e.Writable() {
+		return
+	}
 
-	lastMatchRowIndex := -1
-	searchDirection := 1
+	if e.CY >= len(e.Rows) {
+		return
+	}
 
-	savedHlRowIndex := -1
-	savedHl := []uint8(nil)
+	e.InsertRow(e.CY+1, string(e.Rows[e.CY].chars))
+	e.CY++
+	e.Dirty++
+}
 
-	onKeyPress := func(query string, k key) {
-		if len(savedHl) > 0 {
-			copy(e.Rows[savedHlRowIndex].hl, savedHl)
-			savedHl = []uint8(nil)
-		}
-		switch k {
-		case keyEnter, key('\x1b'):
-			lastMatchRowIndex = -1
-			searchDirection = 1
-			return
-		case keyArrowRight, keyArrowDown:
-			searchDirection = 1
-		case keyArrowLeft, keyArrowUp:
-			searchDirection = -1
-		default:
+func (e *Editor) JoinLines() {
+	if !e.Writable() {
+		return
+	}
 
-			lastMatchRowIndex = -1
-			searchDirection = 1
-		}
+	if e.CY+1 >= len(e.Rows) {
+		return
+	}
 
-		if lastMatchRowIndex == -1 {
-			searchDirection = 1
-		}
+	row := e.Rows[e.CY]
+	next := []rune(strings.TrimLeft(string(e.Rows[e.CY+1].chars), " \t"))
+	joined := strings.TrimRight(string(row.chars), " \t")
+	e.DeleteRunes(e.CY, len([]rune(joined)), len(row.chars))
 
-		current := lastMatchRowIndex
+	e.CX = len(row.chars)
+	if e.Config.JoinWithSpace && e.CX > 0 && len(next) > 0 {
+		e.InsertRunes(e.CY, e.CX, []rune{' '})
+	}
+	e.InsertRunes(e.CY, len(row.chars), next)
+	e.DeleteRow(e.CY + 1)
+}
 
-		for i := 0; i < len(e.Rows); i++ {
-			current += searchDirection
-			switch current {
-			case -1:
-				current = len(e.Rows) - 1
-			case len(e.Rows):
-				current = 0
-			}
+func (e *Editor) Transpose() {
+	if !e.Writable() {
+		return
+	}
 
-			row := e.Rows[current]
-			rx := strings.Index(row.render, query)
-			if rx != -1 {
-				lastMatchRowIndex = current
-				e.CY = current
-				e.CX = e.RowRxToCx(row, rx)
+	if e.CY >= len(e.Rows) || e.CX == 0 {
+		return
+	}
 
-				e.RowOffset = len(e.Rows)
+	row := e.Rows[e.CY]
+	if len(row.chars) < 2 {
+		return
+	}
 
-				savedHlRowIndex = current
-				savedHl = make([]uint8, len(row.hl))
-				copy(savedHl, row.hl)
-				for i := 0; i < utf8.RuneCountInString(query); i++ {
-					row.hl[rx+i] = hlMatch
-				}
-				break
-			}
-		}
+	at := e.CX - 1
+	if e.CX == len(row.chars) {
+		at--
+	}
+	swapped := []rune{row.chars[at+1], row.chars[at]}
+	e.DeleteRunes(e.CY, at, 2)
+	e.InsertRunes(e.CY, at, swapped)
+	e.CX = at + 2
+	e.Dirty++
+}
+
+func (e *Editor) DeleteRow(at int) {
+	if !e.Writable() {
+		return
 	}
 
-	_, err := e.Prompt("Search: %s (ESC = Cancel | Enter = Confirm | Arrows = Prev/Next)", onKeyPress)
-	if err == ErrPromptCanceled {
-		e.CX = savedCx
-		e.CY = savedCy
-		e.ColOffset = savedColOffset
-		e.RowOffset = savedRowOffset
+	if at < 0 || at >= len(e.Rows) {
+		return
+	}
+	e.UnfoldAt(at)
+	e.recordEdit(editOp{kind: editDeleteRow, row: at, chars: append([]rune(nil), e.Rows[at].chars...)})
+	e.Rows = append(e.Rows[:at], e.Rows[at+1:]...)
+	for i := at; i < len(e.Rows); i++ {
+		e.Rows[i].idx--
 	}
-	return err
+	e.shiftMarks(at, -1)
+	e.shiftBookmarks(at, -1)
+	e.shiftSnippetRows(at, -1)
+	e.Dirty++
 }
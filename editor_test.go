@@ -0,0 +1,561 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestEditor(lines ...string) *Editor {
+	e := &Editor{
+		Config: &Config{TabStop: 4, QuitTimes: 3, EmptyLineChar: "~"},
+		Theme:  &ColorPalette{},
+	}
+	e.ScreenCols, e.ScreenRows = 80, 20
+	e.NewBuffer()
+	for i, line := range lines {
+		e.InsertRow(i, line)
+	}
+	e.ResetUndo()
+	return e
+}
+
+// silenceStdout discards what Render writes to the terminal for the rest
+// of the test.
+func silenceStdout(t *testing.T) {
+	t.Helper()
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = null
+	t.Cleanup(func() {
+		os.Stdout = stdout
+		null.Close()
+	})
+}
+
+// typeKeys feeds keys through ProcessKey as if they had been typed.
+func typeKeys(t *testing.T, e *Editor, keys ...key) {
+	t.Helper()
+	e.pendingKeys = append(e.pendingKeys, keys...)
+	for len(e.pendingKeys) > 0 {
+		if err := e.ProcessKey(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return lines
+}
+
+func TestScrollOffShortFile(t *testing.T) {
+	e := newTestEditor(numberedLines(18)...)
+	e.Config.ScrollOff = 3
+	e.CY = 17
+	e.Scroll()
+	if e.RowOffset != 0 {
+		t.Fatalf("RowOffset = %d, want 0", e.RowOffset)
+	}
+
+	var b strings.Builder
+	e.DrawRows(&b)
+}
+
+func TestScrollOffKeepsContext(t *testing.T) {
+	e := newTestEditor(numberedLines(100)...)
+	e.Config.ScrollOff = 3
+
+	for e.CY = 0; e.CY < 30; e.CY++ {
+		e.Scroll()
+	}
+	e.CY = 29
+	e.Scroll()
+	if want := 29 - 20 + 1 + 3; e.RowOffset != want {
+		t.Fatalf("moving down: RowOffset = %d, want %d", e.RowOffset, want)
+	}
+
+	e.CY = e.RowOffset + 1
+	e.Scroll()
+	if want := e.CY - 3; e.RowOffset != want {
+		t.Fatalf("moving up: RowOffset = %d, want %d", e.RowOffset, want)
+	}
+
+	e.CY = 99
+	e.Scroll()
+	if want := 100 + 1 - 20; e.RowOffset != want {
+		t.Fatalf("at the end: RowOffset = %d, want %d", e.RowOffset, want)
+	}
+
+	e.CY = 1
+	e.Scroll()
+	if e.RowOffset != 0 {
+		t.Fatalf("at the start: RowOffset = %d, want 0", e.RowOffset)
+	}
+}
+
+func TestScrollOffClampedToHalfScreen(t *testing.T) {
+	e := newTestEditor(numberedLines(100)...)
+	e.Config.ScrollOff = 50
+	e.CY = 50
+	e.Scroll()
+	if e.CY < e.RowOffset || e.CY >= e.RowOffset+e.TextRows() {
+		t.Fatalf("cursor %d not visible at RowOffset %d", e.CY, e.RowOffset)
+	}
+}
+
+func TestTrailingWhitespaceWithNonASCII(t *testing.T) {
+	e := newTestEditor("héllo wörld  ", "日本語\t", "plain")
+	e.Config.HighlightTrailingWhitespace = true
+	e.CY = 2
+	e.Scroll()
+
+	var b strings.Builder
+	e.DrawRows(&b)
+	lines := strings.Split(b.String(), "\r\n")
+	if !strings.Contains(lines[0], "wörld\x1b[41m  ") {
+		t.Fatalf("trailing spaces not highlighted: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "日本語\x1b[41m ") {
+		t.Fatalf("trailing tab not highlighted: %q", lines[1])
+	}
+}
+
+// Run with -race: resizes and config reloads arrive on their own goroutines
+// while keys are being processed.
+func TestResizeWhileEditing(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor(numberedLines(50)...)
+	e.Render()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			e.Resize(40+i%40, 10+i%20)
+
+			e.mu.Lock()
+			config := *e.Config
+			config.TabStop = 2 + i%4
+			e.Config = &config
+			e.mu.Unlock()
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		typeKeys(t, e, key('x'), keyEnter)
+		e.mu.Lock()
+		e.Render()
+		e.mu.Unlock()
+	}
+	<-done
+
+	if len(e.Rows) != 250 {
+		t.Fatalf("%d rows after 200 edits, want 250", len(e.Rows))
+	}
+}
+
+func TestTruncateLineWideCharAtEdge(t *testing.T) {
+	e := newTestEditor()
+	hl := []uint8{hlNumber, hlString, hlKeyword1}
+
+	line, got := e.TruncateLine("ab日", hl, 3)
+	if line != "ab " || fmt.Sprint(got) != fmt.Sprint([]uint8{hlNumber, hlString, hlNormal}) {
+		t.Fatalf("pad: %q %v", line, got)
+	}
+
+	e.Config.WideCharBoundary = "marker"
+	line, got = e.TruncateLine("ab日", hl, 3)
+	if line != "ab>" || len(got) != 3 {
+		t.Fatalf("marker: %q %v", line, got)
+	}
+
+	line, got = e.TruncateLine("ab日c", []uint8{1, 2, 3, 4}, 4)
+	if line != "ab日" || len(got) != 3 {
+		t.Fatalf("wide char that fits was cut: %q %v", line, got)
+	}
+}
+
+func TestSliceRenderWideCharAtLeftEdge(t *testing.T) {
+	e := newTestEditor("日x")
+	row := e.Rows[0]
+
+	line, hl, start := e.SliceRender(row, 1)
+	if line != " x" || len(hl) != 2 || start != 0 {
+		t.Fatalf("pad: %q %v %d", line, hl, start)
+	}
+
+	e.Config.WideCharBoundary = "marker"
+	if line, _, _ := e.SliceRender(row, 1); line != "<x" {
+		t.Fatalf("marker: %q", line)
+	}
+	if line, _, _ := e.SliceRender(row, 2); line != "x" {
+		t.Fatalf("scrolled past the wide char: %q", line)
+	}
+}
+
+func TestDrawRowsWideCharAtScreenEdge(t *testing.T) {
+	e := newTestEditor()
+	e.InsertRow(0, strings.Repeat("a", e.TextCols()-1)+"日")
+	e.CY = 1
+	e.Scroll()
+
+	var b strings.Builder
+	e.DrawRows(&b)
+	first := strings.Split(b.String(), "\r\n")[0]
+	if strings.Contains(first, "日") {
+		t.Fatalf("wide char drawn past the edge: %q", first)
+	}
+	if !strings.Contains(first, strings.Repeat("a", e.TextCols()-1)+" ") {
+		t.Fatalf("straddling char not replaced by padding: %q", first)
+	}
+}
+
+func newTableEditor(sticky bool) *Editor {
+	long := strings.Repeat("cell | ", 30)
+	e := newTestEditor(long, "short", long, long)
+	e.Config.StickyColOffset = sticky
+	e.CX = 150
+	e.Scroll()
+	return e
+}
+
+func TestStickyColOffsetAcrossShortLines(t *testing.T) {
+	silenceStdout(t)
+	e := newTableEditor(true)
+	start := e.ColOffset
+	if start == 0 {
+		t.Fatal("long line did not scroll")
+	}
+
+	typeKeys(t, e, keyArrowDown)
+	e.Scroll()
+	if e.CX != 5 || e.ColOffset > 5 {
+		t.Fatalf("on the short line: CX = %d, ColOffset = %d", e.CX, e.ColOffset)
+	}
+
+	typeKeys(t, e, keyArrowDown)
+	e.Scroll()
+	if e.CX != 150 || e.ColOffset != start {
+		t.Fatalf("back on a long line: CX = %d, ColOffset = %d, want 150, %d", e.CX, e.ColOffset, start)
+	}
+
+	typeKeys(t, e, keyArrowDown, keyArrowLeft)
+	e.Scroll()
+	if e.CX != 149 || e.ColOffset != start {
+		t.Fatalf("after moving left: CX = %d, ColOffset = %d, want 149, %d", e.CX, e.ColOffset, start)
+	}
+}
+
+func TestColOffsetSnapsWithoutSticky(t *testing.T) {
+	silenceStdout(t)
+	e := newTableEditor(false)
+
+	typeKeys(t, e, keyArrowDown, keyArrowDown)
+	e.Scroll()
+	if e.CX != 5 || e.ColOffset != 5 {
+		t.Fatalf("CX = %d, ColOffset = %d, want 5, 5", e.CX, e.ColOffset)
+	}
+}
+
+func TestStickyColOffsetMargin(t *testing.T) {
+	e := newTableEditor(true)
+	e.Config.ColOffsetMargin = 8
+	e.CX = 0
+	e.Scroll()
+	if e.ColOffset != 0 {
+		t.Fatalf("ColOffset = %d at the start of the line", e.ColOffset)
+	}
+
+	e.CX = e.TextCols()
+	e.Scroll()
+	if want := 1 + 8; e.ColOffset != want {
+		t.Fatalf("ColOffset = %d, want %d", e.ColOffset, want)
+	}
+
+	e.CX = e.ColOffset + e.TextCols() - 1
+	e.Scroll()
+	if e.ColOffset != 9 {
+		t.Fatalf("ColOffset moved to %d while the cursor was visible", e.ColOffset)
+	}
+}
+
+func saveAndRead(t *testing.T, e *Editor) string {
+	t.Helper()
+	if _, err := e.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(e.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSaveUnchangedRoundTrips(t *testing.T) {
+	for _, content := range []string{
+		"one\ntwo\n",
+		"one\ntwo",
+		"one\r\ntwo\r\n",
+		"one\n\n",
+		"\n",
+		"",
+	} {
+		e := openTestFile(t, "round.txt", content)
+		if e.Dirty != 0 {
+			t.Fatalf("%q: Dirty = %d after opening", content, e.Dirty)
+		}
+		for i := 0; i < 2; i++ {
+			if got := saveAndRead(t, e); got != content {
+				t.Fatalf("save %d of %q wrote %q", i+1, content, got)
+			}
+		}
+	}
+}
+
+func TestEnsureFinalNewline(t *testing.T) {
+	e := openTestFile(t, "final.txt", "one\ntwo")
+	e.Config.EnsureFinalNewline = true
+	for i := 0; i < 2; i++ {
+		if got := saveAndRead(t, e); got != "one\ntwo\n" {
+			t.Fatalf("save %d wrote %q", i+1, got)
+		}
+	}
+
+	e = openTestFile(t, "final.txt", "one\ntwo\n")
+	e.Config.EnsureFinalNewline = true
+	if got := saveAndRead(t, e); got != "one\ntwo\n" {
+		t.Fatalf("newline doubled: %q", got)
+	}
+}
+
+func TestOpenStripsBOM(t *testing.T) {
+	e := openTestFile(t, "bom.txt", utf8BOM+"first\nsecond\n")
+	if !e.HasBOM {
+		t.Fatal("BOM not detected")
+	}
+	if got := string(e.Rows[0].chars); got != "first" {
+		t.Fatalf("first row = %q", got)
+	}
+
+	var b strings.Builder
+	e.DrawStatusBar(&b)
+	if !strings.Contains(b.String(), "LF BOM") {
+		t.Fatalf("status bar has no BOM indicator: %q", b.String())
+	}
+
+	if got := saveAndRead(t, e); got != "first\nsecond\n" {
+		t.Fatalf("saved %q without keep_bom", got)
+	}
+}
+
+func TestKeepBOMOnSave(t *testing.T) {
+	e := openTestFile(t, "bom.txt", utf8BOM+"first\n")
+	e.Config.KeepBOM = true
+	if got := saveAndRead(t, e); got != utf8BOM+"first\n" {
+		t.Fatalf("saved %q", got)
+	}
+
+	e = openTestFile(t, "plain.txt", "first\n")
+	e.Config.KeepBOM = true
+	if got := saveAndRead(t, e); got != "first\n" {
+		t.Fatalf("BOM added to a file without one: %q", got)
+	}
+}
+
+func TestRowRxToCx(t *testing.T) {
+	e := newTestEditor("abc", "\t\tx", "a日b", "")
+	tests := []struct {
+		y, rx, want int
+	}{
+		{0, 0, 0},
+		{0, 2, 2},
+		{0, 3, 3},
+		{0, 100, 3},
+		{1, 0, 0},
+		{1, 3, 0},
+		{1, 4, 1},
+		{1, 7, 1},
+		{1, 8, 2},
+		{1, 9, 3},
+		{2, 1, 1},
+		{2, 2, 1},
+		{2, 3, 2},
+		{2, 4, 3},
+		{3, 0, 0},
+		{3, 5, 0},
+	}
+
+	for _, tt := range tests {
+		if got := e.RowRxToCx(e.Rows[tt.y], tt.rx); got != tt.want {
+			t.Errorf("RowRxToCx(%q, %d) = %d, want %d", string(e.Rows[tt.y].chars), tt.rx, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightAlignedWithWideRunes(t *testing.T) {
+	e := newTestEditor()
+	e.Syntax = &EditorSyntax{FileType: "test"}
+	e.Syntax.Flags.HighLightNumbers = true
+	e.Syntax.Flags.HighLightStrings = true
+	e.InsertRow(0, "a日\t\"s\" 42 語x")
+	row := e.Rows[0]
+
+	if row.render != "a日 \"s\" 42 語x" {
+		t.Fatalf("render = %q", row.render)
+	}
+
+	class := func(r rune) uint8 {
+		switch {
+		case r >= '0' && r <= '9':
+			return hlNumber
+		case r == '"' || r == 's':
+			return hlString
+		}
+		return hlNormal
+	}
+	for col := 0; col < 16; col++ {
+		line, hl, _ := e.SliceRender(row, col)
+		runes := []rune(line)
+		if len(runes) != len(hl) {
+			t.Fatalf("col %d: %d runes but %d highlight classes", col, len(runes), len(hl))
+		}
+		for i, r := range runes {
+			if hl[i] != class(r) {
+				t.Fatalf("col %d: %q drawn with class %d, want %d", col, r, hl[i], class(r))
+			}
+		}
+	}
+}
+
+func TestUTF8Slice(t *testing.T) {
+	tests := []struct {
+		s          string
+		start, end int
+		want       string
+	}{
+		{"héllo", 1, 3, "él"},
+		{"héllo", 0, 5, "héllo"},
+		{"héllo", -2, 2, "hé"},
+		{"héllo", 3, 99, "lo"},
+		{"héllo", 7, 9, ""},
+		{"héllo", 4, 2, ""},
+		{"héllo", 2, 2, ""},
+		{"", 0, 1, ""},
+		{"日本語", 1, 2, "本"},
+	}
+
+	for _, tt := range tests {
+		if got := UTF8Slice(tt.s, tt.start, tt.end); got != tt.want {
+			t.Errorf("UTF8Slice(%q, %d, %d) = %q, want %q", tt.s, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestSaveKeepsFileMode(t *testing.T) {
+	e := openTestFile(t, "script.sh", "#!/bin/sh\necho hi\n")
+	if err := os.Chmod(e.Filename, 0755); err != nil {
+		t.Fatal(err)
+	}
+	e.InsertText("# edited\n")
+	saveAndRead(t, e)
+
+	info, err := os.Stat(e.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0755 {
+		t.Fatalf("mode = %o after save, want 755", mode)
+	}
+}
+
+func TestSaveNewFileMode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	e := newTestEditor("secret")
+	e.Config.NewFileMode = "600"
+	e.Filename = filepath.Join(t.TempDir(), "new.txt")
+	saveAndRead(t, e)
+
+	info, err := os.Stat(e.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("mode = %o, want 600", mode)
+	}
+}
+
+func TestSaveThroughSymlink(t *testing.T) {
+	e := openTestFile(t, "target.txt", "old\n")
+	target := e.Filename
+	link := filepath.Join(t.TempDir(), "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Filename = link
+	e.InsertText("new ")
+	saveAndRead(t, e)
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("save replaced the symlink (err %v)", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new old\n" {
+		t.Fatalf("target = %q", data)
+	}
+}
+
+func TestFailedHexWriteNotMarkedWritten(t *testing.T) {
+	e := openTestFile(t, "hex.bin", "abc")
+	if err := e.EnterHexMode(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "dir")
+	if err := os.MkdirAll(filepath.Join(dir, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.WriteFile(dir); err == nil {
+		t.Fatal("writing over a directory succeeded")
+	}
+	if e.hex.written {
+		t.Fatal("failed write marked the hex buffer as written")
+	}
+}
+
+func TestSaveLeavesExistingTmpFileAlone(t *testing.T) {
+	e := openTestFile(t, "foo.txt", "old\n")
+	tmp := e.Filename + ".tmp"
+	if err := os.WriteFile(tmp, []byte("mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e.InsertText("new ")
+	if got := saveAndRead(t, e); got != "new old\n" {
+		t.Fatalf("saved %q", got)
+	}
+	data, err := os.ReadFile(tmp)
+	if err != nil || string(data) != "mine\n" {
+		t.Fatalf("%s = %q, %v after save", tmp, data, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(e.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("save left %d files behind", len(entries)-2)
+	}
+}
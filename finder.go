@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const maxFinderFiles = 20000
+
+var errFinderFull = errors.New("too many files")
+
+type ignoreRule struct {
+	pattern  string
+	dirOnly  bool
+	anchored bool
+}
+
+func readGitignore(root string) []ignoreRule {
+	f, err := os.Open(filepath.Join(root, ".gitignore"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func ignored(rules []ignoreRule, rel string, dir bool) bool {
+	for _, rule := range rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		name := path.Base(rel)
+		if rule.anchored {
+			name = rel
+		}
+		if ok, _ := path.Match(rule.pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func listProjectFiles(root string) ([]string, error) {
+	rules := readGitignore(root)
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" || ignored(rules, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignored(rules, rel, false) {
+			return nil
+		}
+
+		files = append(files, rel)
+		if len(files) >= maxFinderFiles {
+			return errFinderFull
+		}
+		return nil
+	})
+	if err == errFinderFull {
+		err = nil
+	}
+	return files, err
+}
+
+func (e *Editor) FindFile() error {
+	files, err := listProjectFiles(".")
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		e.SetStatusMessage("No files found")
+		return nil
+	}
+
+	filename, err := e.FuzzyPrompt("Find file: %s (Up/Down = Select | Enter = Open | ESC = Cancel)", files, func(file string) string {
+		return file
+	})
+	if err != nil {
+		if err == ErrPromptCanceled {
+			return nil
+		}
+		return err
+	}
+	return e.OpenInNewBuffer(filename)
+}
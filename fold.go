@@ -0,0 +1,109 @@
+package main
+
+import "fmt"
+
+func (e *Editor) ToggleFold() {
+	if e.lazy != nil {
+		e.SetStatusMessage("Large files can't be folded")
+		return
+	}
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	if e.Rows[e.CY].fold > 0 {
+		e.Unfold(e.CY)
+		e.SetStatusMessage("Unfolded")
+		return
+	}
+
+	sy, ey := e.indentedRows()
+	if !e.Selecting {
+		ey = e.indentBlockEnd(sy)
+	}
+	if ey <= sy {
+		e.SetStatusMessage("Nothing to fold")
+		return
+	}
+
+	e.Fold(sy, ey)
+	e.CY, e.CX = sy, 0
+	e.ClearSelection()
+	e.SetStatusMessage("Folded %d lines", ey-sy)
+}
+
+func (e *Editor) indentBlockEnd(y int) int {
+	base := len(e.ExpandTabs([]rune(LeadingWhitespace(e.Rows[y].chars))))
+	end := y
+	for i := y + 1; i < len(e.Rows); i++ {
+		chars := e.Rows[i].chars
+		indent := LeadingWhitespace(chars)
+		if len(indent) == len(chars) {
+			continue
+		}
+		if len(e.ExpandTabs([]rune(indent))) <= base {
+			break
+		}
+		end = i
+	}
+	return end
+}
+
+func (e *Editor) Fold(sy, ey int) {
+	for y := sy; y <= ey; y++ {
+		if e.Rows[y].fold > 0 {
+			e.Unfold(y)
+		}
+	}
+	for y := sy + 1; y <= ey; y++ {
+		e.Rows[y].hidden = true
+	}
+	e.Rows[sy].fold = ey - sy
+	e.folds++
+}
+
+func (e *Editor) Unfold(y int) {
+	row := e.Rows[y]
+	for i := y + 1; i <= y+row.fold && i < len(e.Rows); i++ {
+		e.Rows[i].hidden = false
+	}
+	row.fold = 0
+	e.folds--
+}
+
+func (e *Editor) foldHeader(y int) int {
+	for y > 0 && y < len(e.Rows) && e.Rows[y].hidden {
+		y--
+	}
+	return y
+}
+
+func (e *Editor) UnfoldAt(y int) {
+	if e.folds == 0 || y < 0 || y >= len(e.Rows) {
+		return
+	}
+
+	if h := e.foldHeader(y); e.Rows[h].fold > 0 && y <= h+e.Rows[h].fold {
+		e.Unfold(h)
+	}
+}
+
+func (e *Editor) RevealCursor() {
+	if e.IsHidden(e.CY) {
+		e.UnfoldAt(e.CY)
+	}
+}
+
+func (e *Editor) IsHidden(y int) bool {
+	return e.folds > 0 && y >= 0 && y < len(e.Rows) && e.Rows[y].hidden
+}
+
+func (e *Editor) skipFolded(dir int) {
+	for e.IsHidden(e.CY) {
+		e.CY += dir
+	}
+}
+
+func (e *Editor) FoldSummary(row *Row) string {
+	return fmt.Sprintf(" { ... %d lines }", row.fold)
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestPageSkipsFolds(t *testing.T) {
+	e := newTestEditor(numberedLines(80)...)
+	e.Fold(5, 50)
+
+	typeKeys(t, e, keyPageDown)
+	if e.CY != 51 {
+		t.Fatalf("CY = %d after paging into a fold, want 51", e.CY)
+	}
+	if e.folds != 1 || e.Rows[5].fold != 45 {
+		t.Fatal("paging down expanded the fold")
+	}
+
+	typeKeys(t, e, keyPageUp)
+	if e.CY != 5 {
+		t.Fatalf("CY = %d after paging back into the fold, want 5", e.CY)
+	}
+	if e.folds != 1 || e.Rows[5].fold != 45 {
+		t.Fatal("paging up expanded the fold")
+	}
+}
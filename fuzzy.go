@@ -0,0 +1,107 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+func fuzzyScore(query, target string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(target))
+
+	score, qi, last := 0, 0, -1
+	for i := 0; i < len(t) && qi < len(q); i++ {
+		if t[i] != q[qi] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 4
+		}
+		if i == 0 || !isWordRune(t[i-1]) {
+			score += 2
+		}
+		last = i
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score*8 - len(t), true
+}
+
+func fuzzyFilter(query string, items []string) []string {
+	type match struct {
+		item  string
+		score int
+	}
+
+	var matches []match
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item); ok {
+			matches = append(matches, match{item, score})
+		}
+	}
+	if query != "" {
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	}
+
+	filtered := make([]string, len(matches))
+	for i, m := range matches {
+		filtered[i] = m.item
+	}
+	return filtered
+}
+
+const maxFuzzyResults = 200
+
+func (e *Editor) FuzzyPrompt(prompt string, items []string, label func(string) string) (string, error) {
+	matches, current := items, 0
+	show := func() {
+		if len(matches) > maxFuzzyResults {
+			matches = matches[:maxFuzzyResults]
+		}
+		e.popup = make([]string, len(matches))
+		for i, item := range matches {
+			e.popup[i] = label(item)
+		}
+		e.popupSel = current
+	}
+
+	e.popupBottom = true
+	defer func() {
+		e.popup = nil
+		e.popupBottom = false
+	}()
+	show()
+
+	chosen := ""
+	_, err := e.PromptEdit(func() string { return prompt }, func(query string, k key) string {
+		switch k {
+		case keyArrowUp:
+			if current > 0 {
+				current--
+			}
+		case keyArrowDown:
+			if current < len(matches)-1 {
+				current++
+			}
+		case keyEnter:
+			if len(matches) > 0 {
+				chosen = matches[current]
+			}
+		default:
+			matches, current = fuzzyFilter(query, items), 0
+		}
+		show()
+		return query
+	})
+	if err != nil {
+		return "", err
+	}
+	if chosen == "" {
+		e.SetStatusMessage("No matches")
+		return "", ErrPromptCanceled
+	}
+	return chosen, nil
+}
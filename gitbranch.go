@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func findGitDir(dir string) string {
+	for {
+		path := filepath.Join(dir, ".git")
+		if info, err := os.Stat(path); err == nil {
+			if info.IsDir() {
+				return path
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return ""
+			}
+			gitdir := strings.TrimSpace(strings.TrimPrefix(string(data), "gitdir:"))
+			if !filepath.IsAbs(gitdir) {
+				gitdir = filepath.Join(dir, gitdir)
+			}
+			return gitdir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func (e *Editor) RefreshGitBranch() {
+	e.gitBranch = ""
+	if !e.Config.ShowGitBranch || e.Filename == "" {
+		return
+	}
+
+	gitdir := findGitDir(filepath.Dir(e.absFilename()))
+	if gitdir == "" {
+		return
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(gitdir, "HEAD"))
+	if err != nil {
+		return
+	}
+
+	head := strings.TrimSpace(string(data))
+	if ref := strings.TrimPrefix(head, "ref: "); ref != head {
+		e.gitBranch = strings.TrimPrefix(ref, "refs/heads/")
+	} else if len(head) >= 7 {
+		e.gitBranch = head[:7]
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type gitHunk struct {
+	oldCount        int
+	newStart, count int
+}
+
+func parseHunkRange(s string) (start, count int) {
+	parts := strings.SplitN(s, ",", 2)
+	start, _ = strconv.Atoi(parts[0])
+	count = 1
+	if len(parts) == 2 {
+		count, _ = strconv.Atoi(parts[1])
+	}
+	return start, count
+}
+
+func parseGitHunks(diff []byte) []gitHunk {
+	var hunks []gitHunk
+	s := bufio.NewScanner(bytes.NewReader(diff))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 || fields[0] != "@@" {
+			continue
+		}
+
+		_, oldCount := parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+		newStart, count := parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+		hunks = append(hunks, gitHunk{oldCount, newStart, count})
+	}
+	return hunks
+}
+
+func (e *Editor) clearGitSigns() {
+	for _, row := range e.Rows {
+		if row != nil {
+			row.gitSign = 0
+		}
+	}
+	e.gitSigns = 0
+}
+
+func (e *Editor) RefreshGitSigns() {
+	e.clearGitSigns()
+	if !e.Config.GitSigns || e.Filename == "" || e.lazy != nil {
+		return
+	}
+
+	dir, base := filepath.Split(e.absFilename())
+	cmd := exec.Command("git", "-C", dir, "diff", "--no-color", "-U0", "HEAD", "--", base)
+	out, err := cmd.Output()
+	if err != nil {
+		return
+	}
+
+	mark := func(y int, sign rune) {
+		if y >= 0 && y < len(e.Rows) {
+			e.Rows[y].gitSign = sign
+			e.gitSigns++
+		}
+	}
+
+	for _, h := range parseGitHunks(out) {
+		switch {
+		case h.count == 0:
+			y := h.newStart - 1
+			if y < 0 {
+				y = 0
+			}
+			mark(y, '-')
+		case h.oldCount == 0:
+			for y := h.newStart - 1; y < h.newStart-1+h.count; y++ {
+				mark(y, '+')
+			}
+		default:
+			for y := h.newStart - 1; y < h.newStart-1+h.count; y++ {
+				mark(y, '~')
+			}
+		}
+	}
+}
+
+type gitSigns struct{}
+
+func (gitSigns) HasSigns(e *Editor) bool {
+	return e.gitSigns > 0
+}
+
+func (gitSigns) Sign(e *Editor, row *Row) (Sign, bool) {
+	switch row.gitSign {
+	case '+':
+		return Sign{'+', e.Theme.GitAdded}, true
+	case '~':
+		return Sign{'~', e.Theme.GitModified}, true
+	case '-':
+		return Sign{'-', e.Theme.GitRemoved}, true
+	}
+	return Sign{}, false
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func (e *Editor) GutterWidth() int {
+	if e.hex != nil || e.browser != nil {
+		return 0
+	}
+	width := e.SignColumnWidth() + e.numberWidth()
+	if width >= e.WindowCols() {
+		return 0
+	}
+	return width
+}
+
+func (e *Editor) numberWidth() int {
+	if !e.Config.ShowLineNumbers && !e.Config.RelativeLineNumbers {
+		return 0
+	}
+
+	largest := len(e.Rows)
+	if e.Config.RelativeLineNumbers {
+		last := e.RowOffset + e.TextRows() - 1
+		if last > len(e.Rows)-1 {
+			last = len(e.Rows) - 1
+		}
+
+		largest = e.CY + 1
+		if above := e.CY - e.RowOffset; above > largest {
+			largest = above
+		}
+		if below := last - e.CY; below > largest {
+			largest = below
+		}
+	}
+
+	return len(strconv.Itoa(largest)) + 1
+}
+
+func (e *Editor) TextCols() int {
+	return e.WindowCols() - e.GutterWidth()
+}
+
+func (e *Editor) LineNumber(filerow int) int {
+	if !e.Config.RelativeLineNumbers {
+		return filerow + 1
+	}
+
+	if filerow == e.CY {
+		return filerow + 1
+	}
+	if filerow < e.CY {
+		return e.CY - filerow
+	}
+	return filerow - e.CY
+}
+
+func (e *Editor) DrawGutter(b *strings.Builder, filerow int) {
+	if e.GutterWidth() == 0 {
+		return
+	}
+
+	if e.SignColumnWidth() > 0 {
+		e.DrawSign(b, filerow)
+	}
+
+	width := e.numberWidth()
+	if width == 0 {
+		return
+	}
+
+	if filerow < 0 || filerow >= len(e.Rows) {
+		b.WriteString(strings.Repeat(" ", width))
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("%s%*d \x1b[39m", e.Fg(e.Theme.LineNumber), width-1, e.LineNumber(filerow)))
+}
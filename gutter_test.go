@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestRelativeLineNumbers(t *testing.T) {
+	e := newTestEditor(numberedLines(30)...)
+	e.Config.RelativeLineNumbers = true
+	e.CY = 12
+
+	for _, tt := range []struct{ row, want int }{
+		{12, 13},
+		{11, 1},
+		{0, 12},
+		{13, 1},
+		{29, 17},
+	} {
+		if got := e.LineNumber(tt.row); got != tt.want {
+			t.Errorf("LineNumber(%d) = %d, want %d", tt.row, got, tt.want)
+		}
+	}
+
+	e.Config.ShowLineNumbers = true
+	if got := e.LineNumber(12); got != 13 {
+		t.Errorf("LineNumber(12) = %d with show_line_numbers, want 13", got)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type hexView struct {
+	data    []byte
+	cursor  int
+	nibble  int
+	rowOff  int
+	written bool
+}
+
+func isHexAction(action string) bool {
+	switch action {
+	case "quit", "save", "save-as", "redraw", "escape", "hex-mode", "toggle-read-only", "reload-config",
+		"next-buffer", "previous-buffer", "buffer-1", "buffer-2", "buffer-3", "buffer-4", "buffer-5",
+		"buffer-6", "buffer-7", "buffer-8", "buffer-9":
+		return true
+	}
+	return false
+}
+
+func hexDigit(k key) (byte, bool) {
+	switch {
+	case k >= '0' && k <= '9':
+		return byte(k - '0'), true
+	case k >= 'a' && k <= 'f':
+		return byte(k-'a') + 10, true
+	case k >= 'A' && k <= 'F':
+		return byte(k-'A') + 10, true
+	}
+	return 0, false
+}
+
+func (e *Editor) ToggleHexMode() error {
+	if e.hex != nil {
+		return e.LeaveHexMode()
+	}
+	return e.EnterHexMode()
+}
+
+func (e *Editor) EnterHexMode() error {
+	if e.lazy != nil {
+		e.SetStatusMessage("Large files can't be shown in hex")
+		return nil
+	}
+	if e.Dirty > 0 {
+		e.SetStatusMessage("Save %s before switching to hex mode", e.DisplayName())
+		return nil
+	}
+
+	data := []byte(e.RowsToString())
+	if e.Filename != "" {
+		raw, err := os.ReadFile(e.Filename)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil {
+			data = raw
+		}
+	}
+
+	e.CollapseCursors()
+	e.ClearSelection()
+	e.hex = &hexView{data: data}
+	e.SetStatusMessage("Hex mode: %d bytes (Alt-H to leave)", len(data))
+	return nil
+}
+
+func (e *Editor) LeaveHexMode() error {
+	if e.Dirty > 0 {
+		e.SetStatusMessage("Save or discard hex changes before leaving hex mode")
+		return nil
+	}
+
+	written := e.hex.written
+	e.hex = nil
+	e.InvalidateScreen()
+	if written {
+		return e.ReloadFile()
+	}
+	e.SetStatusMessage("")
+	return nil
+}
+
+func (e *Editor) hexWidth() int {
+	if e.TextCols() >= 78 {
+		return 16
+	}
+	return 8
+}
+
+func (e *Editor) HexKey(action string, k key) bool {
+	if isHexAction(action) {
+		return false
+	}
+
+	h := e.hex
+	width := e.hexWidth()
+	switch action {
+	case "left", "backspace":
+		if h.nibble == 1 {
+			h.nibble = 0
+		} else if h.cursor > 0 {
+			h.cursor--
+			h.nibble = 1
+		}
+	case "right":
+		if h.nibble == 0 && h.cursor < len(h.data) {
+			h.nibble = 1
+		} else if h.cursor < len(h.data) {
+			h.cursor++
+			h.nibble = 0
+		}
+	case "up":
+		if h.cursor >= width {
+			h.cursor -= width
+		}
+	case "down":
+		h.cursor += width
+	case "home":
+		h.cursor -= h.cursor % width
+		h.nibble = 0
+	case "end":
+		h.cursor += width - 1 - h.cursor%width
+		h.nibble = 1
+	case "page-up":
+		h.cursor -= width * e.TextRows()
+	case "page-down":
+		h.cursor += width * e.TextRows()
+	case "top":
+		h.cursor, h.nibble = 0, 0
+	case "bottom":
+		h.cursor, h.nibble = len(h.data), 0
+	case "":
+		d, ok := hexDigit(k)
+		if !ok {
+			e.SetStatusMessage("Type hex digits to edit bytes")
+			return true
+		}
+		e.SetHexNibble(d)
+	default:
+		e.SetStatusMessage("Not available in hex mode")
+		return true
+	}
+
+	if h.cursor < 0 {
+		h.cursor = 0
+	}
+	if h.cursor >= len(h.data) {
+		h.cursor, h.nibble = len(h.data), 0
+	}
+	return true
+}
+
+func (e *Editor) SetHexNibble(d byte) {
+	if !e.Writable() {
+		return
+	}
+
+	h := e.hex
+	if h.cursor == len(h.data) {
+		h.data = append(h.data, 0)
+	}
+
+	if h.nibble == 0 {
+		h.data[h.cursor] = d<<4 | h.data[h.cursor]&0x0f
+		h.nibble = 1
+	} else {
+		h.data[h.cursor] = h.data[h.cursor]&0xf0 | d
+		h.cursor++
+		h.nibble = 0
+	}
+	e.Dirty++
+}
+
+func (e *Editor) ScrollHex() {
+	h := e.hex
+	row := h.cursor / e.hexWidth()
+	if row < h.rowOff {
+		h.rowOff = row
+	}
+	if row >= h.rowOff+e.TextRows() {
+		h.rowOff = row - e.TextRows() + 1
+	}
+}
+
+func (e *Editor) hexColumn(i int) int {
+	col := 10 + i*3
+	if i >= 8 {
+		col++
+	}
+	return col
+}
+
+func (e *Editor) HexCursor() (int, int) {
+	h := e.hex
+	width := e.hexWidth()
+	return h.cursor/width - h.rowOff, e.hexColumn(h.cursor%width) + h.nibble
+}
+
+func (e *Editor) DrawHexRows(b *strings.Builder) {
+	h := e.hex
+	width := e.hexWidth()
+	for y := 0; y < e.TextRows(); y++ {
+		offset := (h.rowOff + y) * width
+		if offset > len(h.data) {
+			b.WriteString(e.Config.EmptyLineChar)
+		} else {
+			e.DrawHexLine(b, offset, width)
+		}
+		b.WriteString("\x1b[K\r\n")
+	}
+}
+
+func (e *Editor) DrawHexLine(b *strings.Builder, offset, width int) {
+	h := e.hex
+	end := offset + width
+	if end > len(h.data) {
+		end = len(h.data)
+	}
+
+	b.WriteString(e.Fg(e.Theme.LineNumber))
+	fmt.Fprintf(b, "%08x", offset)
+	b.WriteString("\x1b[39m  ")
+
+	for i := 0; i < width; i++ {
+		if i == 8 {
+			b.WriteString(" ")
+		}
+		if offset+i < end {
+			fmt.Fprintf(b, "%02x ", h.data[offset+i])
+		} else {
+			b.WriteString("   ")
+		}
+	}
+
+	b.WriteString(" |")
+	for i := offset; i < end; i++ {
+		c := h.data[i]
+		if c < ' ' || c > '~' {
+			c = '.'
+		}
+		if i == h.cursor {
+			b.WriteString("\x1b[7m")
+			b.WriteByte(c)
+			b.WriteString("\x1b[27m")
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	b.WriteString("|")
+}
@@ -0,0 +1,50 @@
+package main
+
+import "time"
+
+const defaultIdleDelay = 1000
+
+func (e *Editor) OnIdle(fn func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.idleCallbacks = append(e.idleCallbacks, fn)
+}
+
+func (e *Editor) MarkInput() {
+	e.lastInput = time.Now()
+	e.idleFired = false
+}
+
+func (e *Editor) IdleDelay() time.Duration {
+	delay := e.Config.IdleDelay
+	if delay <= 0 {
+		delay = defaultIdleDelay
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+func (e *Editor) RunIdle() {
+	if !e.mu.TryLock() {
+		return
+	}
+	defer e.mu.Unlock()
+
+	if e.prompting || e.idleFired || time.Since(e.lastInput) < e.IdleDelay() {
+		return
+	}
+
+	for _, fn := range e.idleCallbacks {
+		fn()
+	}
+	e.idleFired = true
+}
+
+func (e *Editor) RehighlightAll() {
+	for _, row := range e.Rows {
+		if row == nil {
+			continue
+		}
+		e.UpdateHighlight(row)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func (e *Editor) InsertTab() {
+	if !e.Writable() {
+		return
+	}
+
+	if !e.Config.SoftTabs {
+		e.InsertChar('\t')
+		return
+	}
+
+	rx := 0
+	if e.CY < len(e.Rows) {
+		rx = e.RowCxToRx(e.Rows[e.CY], e.CX)
+	}
+
+	for i := e.TabStop() - rx%e.TabStop(); i > 0; i-- {
+		e.InsertChar(' ')
+	}
+}
+
+func (e *Editor) indentedRows() (int, int) {
+	sy, ey := e.CY, e.CY
+	if s, _, end, ex, ok := e.SelectionBounds(); ok {
+		sy, ey = s, end
+		if ex == 0 && ey > sy {
+			ey--
+		}
+	}
+	if ey >= len(e.Rows) {
+		ey = len(e.Rows) - 1
+	}
+	return sy, ey
+}
+
+func (e *Editor) shiftRow(y, delta int) {
+	if e.CY == y {
+		e.CX += delta
+		if e.CX < 0 {
+			e.CX = 0
+		}
+	}
+	if e.Selecting && e.SelAnchorY == y {
+		e.SelAnchorX += delta
+		if e.SelAnchorX < 0 {
+			e.SelAnchorX = 0
+		}
+	}
+}
+
+func (e *Editor) IndentRows() {
+	if !e.Writable() {
+		return
+	}
+
+	unit := []rune("\t")
+	if e.Config.SoftTabs {
+		unit = []rune(strings.Repeat(" ", e.TabStop()))
+	}
+
+	sy, ey := e.indentedRows()
+	changed := false
+	for y := sy; y <= ey; y++ {
+		if len(e.Rows[y].chars) == 0 {
+			continue
+		}
+		e.InsertRunes(y, 0, unit)
+		e.shiftRow(y, len(unit))
+		changed = true
+	}
+
+	if changed {
+		e.Dirty++
+	}
+}
+
+func (e *Editor) DedentRows() {
+	if !e.Writable() {
+		return
+	}
+
+	sy, ey := e.indentedRows()
+	changed := false
+	for y := sy; y <= ey; y++ {
+		n := e.dedentLen(e.Rows[y].chars)
+		if n == 0 {
+			continue
+		}
+
+		e.DeleteRunes(y, 0, n)
+		e.shiftRow(y, -n)
+		changed = true
+	}
+
+	if changed {
+		e.Dirty++
+	}
+}
+
+func (e *Editor) dedentLen(chars []rune) int {
+	if len(chars) > 0 && chars[0] == '\t' {
+		return 1
+	}
+
+	n := 0
+	for n < len(chars) && n < e.TabStop() && chars[n] == ' ' {
+		n++
+	}
+	return n
+}
+
+func (e *Editor) ElectricDedent(closer rune) {
+	chars := e.Rows[e.CY].chars
+	if strings.TrimSpace(string(chars)) != string(closer) {
+		return
+	}
+
+	if n := e.dedentLen(chars); n > 0 {
+		e.DeleteRunes(e.CY, 0, n)
+		e.CX -= n
+	}
+}
+
+func (e *Editor) DeleteIndent() bool {
+	if !e.Config.SoftTabs || e.CY >= len(e.Rows) || e.CX == 0 {
+		return false
+	}
+
+	before := string(e.Rows[e.CY].chars[:e.CX])
+	if strings.TrimLeft(before, " ") != "" {
+		return false
+	}
+
+	n := (e.CX-1)%e.TabStop() + 1
+	e.DeleteRunes(e.CY, e.CX-n, n)
+	e.CX -= n
+	e.Dirty++
+	return true
+}
+
+func (e *Editor) ExpandTabs(chars []rune) []rune {
+	expanded := make([]rune, 0, len(chars))
+	col := 0
+	for _, r := range chars {
+		if r == '\t' {
+			for n := e.TabStop() - col%e.TabStop(); n > 0; n-- {
+				expanded = append(expanded, ' ')
+				col++
+			}
+			continue
+		}
+		expanded = append(expanded, r)
+		col += runewidth.RuneWidth(r)
+	}
+	return expanded
+}
+
+func (e *Editor) TabifyIndent(chars []rune) []rune {
+	indent := LeadingWhitespace(chars)
+	width := len(e.ExpandTabs([]rune(indent)))
+
+	tabified := []rune(strings.Repeat("\t", width/e.TabStop()) + strings.Repeat(" ", width%e.TabStop()))
+	return append(tabified, chars[len([]rune(indent)):]...)
+}
+
+func (e *Editor) Retab(convert func([]rune) []rune) int {
+	rx := 0
+	if e.CY < len(e.Rows) {
+		rx = e.RowCxToRx(e.Rows[e.CY], e.CX)
+	}
+
+	changed := 0
+	for y, row := range e.Rows {
+		converted := convert(row.chars)
+		if string(converted) == string(row.chars) {
+			continue
+		}
+
+		e.DeleteRunes(y, 0, len(row.chars))
+		e.InsertRunes(y, 0, converted)
+		changed++
+	}
+
+	if changed > 0 {
+		e.Dirty++
+	}
+
+	if e.CY < len(e.Rows) {
+		row := e.Rows[e.CY]
+		e.CX = 0
+		for e.CX < len(row.chars) && e.RowCxToRx(row, e.CX) < rx {
+			e.CX++
+		}
+	}
+	return changed
+}
+
+func (e *Editor) ConvertIndentation() error {
+	if !e.Writable() {
+		return nil
+	}
+
+	choice, err := e.Prompt("Convert indentation to: %s (spaces/tabs, ESC to cancel)", nil)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(choice)) {
+	case "spaces":
+		e.SetStatusMessage("Converted tabs to spaces on %d lines", e.Retab(e.ExpandTabs))
+	case "tabs":
+		e.SetStatusMessage("Converted spaces to tabs on %d lines", e.Retab(e.TabifyIndent))
+	default:
+		e.SetStatusMessage("Unknown indentation %q", choice)
+	}
+	return nil
+}
+
+func (e *Editor) WhitespaceMarkers(row *Row) []rune {
+	markers := make([]rune, 0, len(row.chars))
+	trailing := len(row.chars)
+	for trailing > 0 && (row.chars[trailing-1] == ' ' || row.chars[trailing-1] == '\t') {
+		trailing--
+	}
+
+	col := 0
+	for cx, r := range row.chars {
+		switch {
+		case r == '\t':
+			markers = append(markers, '»')
+			col++
+			for col%e.TabStop() != 0 {
+				markers = append(markers, '·')
+				col++
+			}
+		case r == ' ' && cx >= trailing:
+			markers = append(markers, '·')
+			col++
+		default:
+			markers = append(markers, 0)
+			col += runewidth.RuneWidth(r)
+		}
+	}
+	return markers
+}
+
+func (e *Editor) IsColorColumn(col, width int) bool {
+	for _, c := range e.Config.ColorColumn {
+		if c > 0 && c-1 >= col && c-1 < col+width {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Editor) LastColorColumn(limit int) int {
+	last := -1
+	for _, c := range e.Config.ColorColumn {
+		if c-1 < limit && c-1 > last {
+			last = c - 1
+		}
+	}
+	return last
+}
@@ -0,0 +1,74 @@
+package main
+
+const maxJumps = 100
+
+type jump struct {
+	filename string
+	cx, cy   int
+}
+
+func (e *Editor) RecordJump(cx, cy int) {
+	j := jump{e.Filename, cx, cy}
+	e.jumps = e.jumps[:e.jumpIdx]
+	if n := len(e.jumps); n == 0 || e.jumps[n-1] != j {
+		e.jumps = append(e.jumps, j)
+	}
+	if len(e.jumps) > maxJumps {
+		e.jumps = e.jumps[len(e.jumps)-maxJumps:]
+	}
+	e.jumpIdx = len(e.jumps)
+}
+
+func (e *Editor) JumpBack() error {
+	if e.jumpIdx == len(e.jumps) {
+		e.RecordJump(e.CX, e.CY)
+		e.jumpIdx = len(e.jumps) - 1
+	}
+	if e.jumpIdx <= 0 {
+		e.SetStatusMessage("At the oldest jump")
+		return nil
+	}
+
+	e.jumpIdx--
+	return e.gotoJump(e.jumps[e.jumpIdx])
+}
+
+func (e *Editor) JumpForward() error {
+	if e.jumpIdx >= len(e.jumps)-1 {
+		e.SetStatusMessage("At the newest jump")
+		return nil
+	}
+
+	e.jumpIdx++
+	return e.gotoJump(e.jumps[e.jumpIdx])
+}
+
+func (e *Editor) gotoJump(j jump) error {
+	if j.filename != e.Filename {
+		found := false
+		for i, b := range e.Buffers {
+			if b.Filename == j.filename {
+				e.SelectBuffer(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			if j.filename == "" {
+				e.SetStatusMessage("Buffer was closed")
+				return nil
+			}
+			idx, jumps := e.jumpIdx, e.jumps
+			if err := e.OpenInNewBuffer(j.filename); err != nil {
+				return err
+			}
+			e.jumpIdx, e.jumps = idx, jumps
+		}
+	}
+
+	if e.RestoreCursor(j.cx, j.cy, e.RowOffset) {
+		e.SetStatusMessage("Jump target no longer exists")
+	}
+	e.Scroll()
+	return nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const KEYS_FILE = ".config/cookie/keys.json"
+
+type keySpecs []string
+
+func (s *keySpecs) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = keySpecs{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("key bindings must be a string or a list of strings: %s", data)
+	}
+	*s = many
+	return nil
+}
+
+var defaultKeymap = map[string]keySpecs{
+	"newline":                {"enter"},
+	"quit":                   {"ctrl-q"},
+	"save":                   {"ctrl-s"},
+	"save-as":                {"ctrl-o"},
+	"find":                   {"ctrl-f"},
+	"replace":                {"ctrl-r"},
+	"goto-line":              {"ctrl-g"},
+	"delete-line":            {"ctrl-d"},
+	"toggle-selection":       {"ctrl-space"},
+	"copy":                   {"ctrl-c"},
+	"cut":                    {"ctrl-x"},
+	"paste":                  {"ctrl-v"},
+	"undo":                   {"ctrl-z"},
+	"redo":                   {"ctrl-y"},
+	"normalize-line-endings": {"ctrl-e"},
+	"home":                   {"home"},
+	"end":                    {"end"},
+	"tab":                    {"tab"},
+	"dedent":                 {"shift-tab"},
+	"backspace":              {"backspace", "ctrl-h"},
+	"delete":                 {"delete"},
+	"top":                    {"ctrl-home"},
+	"bottom":                 {"ctrl-end"},
+	"page-up":                {"page-up"},
+	"page-down":              {"page-down"},
+	"up":                     {"up"},
+	"down":                   {"down"},
+	"left":                   {"left"},
+	"right":                  {"right"},
+	"delete-word-left":       {"ctrl-w"},
+	"delete-word-right":      {"ctrl-delete"},
+	"word-left":              {"ctrl-left"},
+	"word-right":             {"ctrl-right"},
+	"move-line-up":           {"alt-up"},
+	"move-line-down":         {"alt-down"},
+	"redraw":                 {"ctrl-l"},
+	"escape":                 {"esc"},
+	"add-cursor":             {"alt-n"},
+	"jump-back":              {"alt-left"},
+	"jump-forward":           {"alt-right"},
+	"set-mark":               {"alt-m"},
+	"jump-to-mark":           {"alt-g"},
+	"duplicate-line":         {"alt-d"},
+	"open":                   {"alt-o"},
+	"open-recent":            {"alt-e"},
+	"next-buffer":            {"ctrl-n"},
+	"previous-buffer":        {"ctrl-p"},
+	"buffer-1":               {"alt-1"},
+	"buffer-2":               {"alt-2"},
+	"buffer-3":               {"alt-3"},
+	"buffer-4":               {"alt-4"},
+	"buffer-5":               {"alt-5"},
+	"buffer-6":               {"alt-6"},
+	"buffer-7":               {"alt-7"},
+	"buffer-8":               {"alt-8"},
+	"buffer-9":               {"alt-9"},
+	"fold":                   {"alt-f"},
+	"bookmark":               {"alt-b"},
+	"complete":               {"alt-/"},
+	"next-bookmark":          {"alt-."},
+	"previous-bookmark":      {"alt-,"},
+	"toggle-read-only":       {"alt-r"},
+	"reload-file":            {"alt-l"},
+	"reload-config":          {"alt-c"},
+	"convert-indentation":    {"alt-t"},
+	"insert-date":            {"alt-i"},
+	"join-lines":             {"alt-j"},
+	"transpose":              {"ctrl-t"},
+	"upper-case":             {"alt-u"},
+	"lower-case":             {"alt-k"},
+	"title-case":             {"alt-y"},
+	"word-count":             {"alt-w"},
+	"hex-mode":               {"alt-h"},
+	"browse":                 {"ctrl-b"},
+	"command-palette":        {"alt-x"},
+	"find-file":              {"alt-p"},
+	"split-vertical":         {"alt-v"},
+	"other-window":           {"alt-a"},
+	"split-horizontal":       {"alt-s"},
+	"close-window":           {"alt-q"},
+	"undo-history":           {},
+}
+
+var namedKeys = map[string]key{
+	"enter":       keyEnter,
+	"tab":         key('\t'),
+	"shift-tab":   keyShiftTab,
+	"backspace":   keyBackspace,
+	"delete":      keyDelete,
+	"ctrl-delete": keyCtrlDelete,
+	"esc":         key('\x1b'),
+	"ctrl-space":  key(ctrl('@')),
+	"home":        keyHome,
+	"end":         keyEnd,
+	"ctrl-home":   keyCtrlHome,
+	"ctrl-end":    keyCtrlEnd,
+	"page-up":     keyPageUp,
+	"page-down":   keyPageDown,
+	"up":          keyArrowUp,
+	"down":        keyArrowDown,
+	"left":        keyArrowLeft,
+	"right":       keyArrowRight,
+	"ctrl-left":   keyCtrlArrowLeft,
+	"ctrl-right":  keyCtrlArrowRight,
+	"alt-up":      keyAltArrowUp,
+	"alt-down":    keyAltArrowDown,
+	"alt-left":    keyAltArrowLeft,
+	"alt-right":   keyAltArrowRight,
+}
+
+func parseKeySpec(spec string) (key, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if k, ok := namedKeys[spec]; ok {
+		return k, nil
+	}
+
+	if c := strings.TrimPrefix(spec, "ctrl-"); c != spec && len(c) == 1 && c[0] >= 'a' && c[0] <= 'z' {
+		return key(ctrl(c[0])), nil
+	}
+	if c := strings.TrimPrefix(spec, "alt-"); c != spec && len(c) == 1 && c[0] > ' ' && c[0] < utf8.RuneSelf {
+		return alt(c[0]), nil
+	}
+	return 0, fmt.Errorf("unknown key %q", spec)
+}
+
+func keyName(k key) string {
+	for name, named := range namedKeys {
+		if named == k {
+			return name
+		}
+	}
+
+	switch {
+	case k > keyAltBase && k < keyAltBase+utf8.RuneSelf:
+		return "alt-" + string(rune(k-keyAltBase))
+	case k >= 1 && k <= 26:
+		return "ctrl-" + string(rune('a'+k-1))
+	}
+	return ""
+}
+
+// BuildKeymap binds the user's keys on top of the defaults. An action listed
+// by the user loses its default keys, and a default binding whose key the
+// user has taken is dropped.
+func BuildKeymap(user map[string]keySpecs) (map[key]string, error) {
+	actions := make([]string, 0, len(user))
+	for action := range user {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	keymap := map[key]string{}
+	var problems []string
+	for _, action := range actions {
+		if _, ok := defaultKeymap[action]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown action %q", action))
+			continue
+		}
+
+		for _, spec := range user[action] {
+			k, err := parseKeySpec(spec)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", action, err))
+				continue
+			}
+			if other, ok := keymap[k]; ok {
+				problems = append(problems, fmt.Sprintf("%s is bound to both %s and %s", spec, other, action))
+				continue
+			}
+			keymap[k] = action
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New("invalid key bindings: " + strings.Join(problems, "; "))
+	}
+
+	for action, specs := range defaultKeymap {
+		if _, ok := user[action]; ok {
+			continue
+		}
+		for _, spec := range specs {
+			k, err := parseKeySpec(spec)
+			if err != nil {
+				continue
+			}
+			if _, ok := keymap[k]; !ok {
+				keymap[k] = action
+			}
+		}
+	}
+	return keymap, nil
+}
+
+func HandleKeymap() (map[key]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.New("failed to get home directory")
+	}
+
+	keysFile := homeDir + "/" + KEYS_FILE
+	if _, err := os.Stat(keysFile); err != nil {
+		if err := os.MkdirAll(homeDir+"/.config/cookie", 0755); err != nil {
+			return nil, errors.New("failed to create config directory")
+		}
+
+		if err := ioutil.WriteFile(keysFile, []byte(startingKeysJson), 0644); err != nil {
+			return nil, errors.New("failed to create keys file")
+		}
+	}
+
+	file, err := ioutil.ReadFile(keysFile)
+	if err != nil {
+		return nil, errors.New("failed to read keys file")
+	}
+
+	user := map[string]keySpecs{}
+	if err := json.Unmarshal(file, &user); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal keys file: %v", err)
+	}
+
+	return BuildKeymap(user)
+}
+
+func (e *Editor) Action(k key) string {
+	if e.Keymap == nil {
+		e.Keymap, _ = BuildKeymap(nil)
+	}
+	return e.Keymap[k]
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultKeymapHasNoConflicts(t *testing.T) {
+	keymap, err := BuildKeymap(defaultKeymap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults, _ := BuildKeymap(nil); len(defaults) != len(keymap) {
+		t.Fatalf("defaults bind %d keys, want %d", len(defaults), len(keymap))
+	}
+}
+
+func TestUserBindingOverridesDefault(t *testing.T) {
+	keymap, err := BuildKeymap(map[string]keySpecs{"save": {"ctrl-q"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := keymap[key(ctrl('q'))]; got != "save" {
+		t.Fatalf("ctrl-q = %q, want save", got)
+	}
+	if got, ok := keymap[key(ctrl('s'))]; ok {
+		t.Fatalf("ctrl-s still bound to %q after save was rebound", got)
+	}
+	if got := keymap[key(ctrl('f'))]; got != "find" {
+		t.Fatalf("ctrl-f = %q, want the default find", got)
+	}
+}
+
+func TestUserBindingConflicts(t *testing.T) {
+	_, err := BuildKeymap(map[string]keySpecs{"save": {"ctrl-q"}, "quit": {"ctrl-q"}})
+	if err == nil || !strings.Contains(err.Error(), "ctrl-q is bound to both quit and save") {
+		t.Fatalf("err = %v, want a conflict between quit and save", err)
+	}
+
+	_, err = BuildKeymap(map[string]keySpecs{"no-such-action": {"ctrl-q"}})
+	if err == nil || !strings.Contains(err.Error(), "unknown action") {
+		t.Fatalf("err = %v, want unknown action", err)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+const lazyChunkSize = 1 << 20
+
+type lazyFile struct {
+	f       *os.File
+	size    int64
+	offsets []int64
+	loaded  map[int]bool
+}
+
+func (e *Editor) IsLargeFile(size int64) bool {
+	return e.Config.LargeFileMB > 0 && size >= int64(e.Config.LargeFileMB)<<20
+}
+
+func (e *Editor) OpenLargeFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	lazy := &lazyFile{f: f, offsets: []int64{0}, loaded: map[int]bool{}}
+	buf := make([]byte, lazyChunkSize)
+	var pos int64
+	var last byte
+	for {
+		n, err := f.Read(buf)
+		chunk := buf[:n]
+		for i := bytes.IndexByte(chunk, '\n'); i != -1; i = bytes.IndexByte(chunk, '\n') {
+			lazy.offsets = append(lazy.offsets, pos+int64(i)+1)
+			pos += int64(i) + 1
+			chunk = chunk[i+1:]
+		}
+		pos += int64(len(chunk))
+		if n > 0 {
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return err
+		}
+	}
+	lazy.size = pos
+	if lazy.offsets[len(lazy.offsets)-1] == pos {
+		lazy.offsets = lazy.offsets[:len(lazy.offsets)-1]
+	}
+
+	e.lazy = lazy
+	e.Rows = make([]*Row, len(lazy.offsets))
+	e.LineEnding = "\n"
+	if first := e.readLazyLine(0); strings.HasSuffix(first, "\r\n") {
+		e.LineEnding = "\r\n"
+	}
+	e.NoFinalNewline = last != '\n'
+	e.ReadOnly = true
+	e.Dirty = 0
+	e.SetStatusMessage("%s is a large file, opened read-only (%d lines)", e.DisplayName(), len(e.Rows))
+	return nil
+}
+
+func (e *Editor) CloseLargeFile() {
+	if e.lazy == nil {
+		return
+	}
+	e.lazy.f.Close()
+	e.lazy = nil
+}
+
+func (e *Editor) readLazyLine(at int) string {
+	if at >= len(e.lazy.offsets) {
+		return ""
+	}
+
+	start, end := e.lazy.offsets[at], e.lazy.size
+	if at+1 < len(e.lazy.offsets) {
+		end = e.lazy.offsets[at+1]
+	}
+
+	buf := make([]byte, end-start)
+	n, err := e.lazy.f.ReadAt(buf, start)
+	if err != nil && err != io.EOF {
+		Debugf("read line %d failed: %v", at, err)
+	}
+	return string(buf[:n])
+}
+
+func (e *Editor) lazyRow(at int) *Row {
+	line := strings.TrimSuffix(e.readLazyLine(at), "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if at == 0 {
+		line = strings.TrimPrefix(line, utf8BOM)
+	}
+
+	row := &Row{idx: at, chars: []rune(line)}
+	e.UpdateRow(row)
+	return row
+}
+
+func (e *Editor) RowAt(at int) *Row {
+	if row := e.Rows[at]; row != nil {
+		return row
+	}
+	return e.lazyRow(at)
+}
+
+func (e *Editor) LoadRow(at int) *Row {
+	if e.Rows[at] == nil {
+		e.Rows[at] = e.lazyRow(at)
+		e.lazy.loaded[at] = true
+	}
+	return e.Rows[at]
+}
+
+func (e *Editor) LoadRows() {
+	if e.lazy == nil || len(e.Rows) == 0 {
+		return
+	}
+
+	window := 2 * e.TextRows()
+	lo, hi := e.CY-window, e.CY+window
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(e.Rows) {
+		hi = len(e.Rows)
+	}
+
+	for at := range e.lazy.loaded {
+		if at < lo || at >= hi {
+			e.Rows[at] = nil
+			delete(e.lazy.loaded, at)
+		}
+	}
+	for at := lo; at < hi; at++ {
+		e.LoadRow(at)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func openLargeTestFile(t *testing.T, lines int) *Editor {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	var b strings.Builder
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&b, "line %d of the large test file\n", i+1)
+	}
+	filename := filepath.Join(t.TempDir(), "large.txt")
+	if err := os.WriteFile(filename, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestEditor()
+	e.Config.LargeFileMB = 1
+	if err := e.OpenFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if e.lazy == nil {
+		t.Fatal("file was not opened lazily")
+	}
+	t.Cleanup(e.CloseLargeFile)
+	return e
+}
+
+func TestReloadLargeFileKeepsCursor(t *testing.T) {
+	e := openLargeTestFile(t, 80000)
+	e.CY, e.CX = 50000, 7
+	e.Scroll()
+
+	if err := e.ReloadFile(); err != nil {
+		t.Fatal(err)
+	}
+	if e.CY != 50000 || e.CX != 7 {
+		t.Fatalf("cursor = %d,%d, want 50000,7", e.CY, e.CX)
+	}
+}
+
+func TestGotoMarkInLargeFile(t *testing.T) {
+	e := openLargeTestFile(t, 80000)
+	e.marks = map[rune]cursor{'a': {100, 60000}}
+
+	e.GotoMark('a')
+	want := len("line 60001 of the large test file")
+	if e.CY != 60000 || e.CX != want {
+		t.Fatalf("cursor = %d,%d, want 60000,%d", e.CY, e.CX, want)
+	}
+}
+
+func TestAddCursorInLargeFile(t *testing.T) {
+	e := openLargeTestFile(t, 80000)
+	e.CY, e.CX = 0, 5
+	e.Scroll()
+
+	e.AddCursor()
+	if len(e.cursors) != 0 || e.CY != 0 {
+		t.Fatalf("found another occurrence of a unique word at line %d", e.CY+1)
+	}
+}
+
+func TestPageThroughLargeFile(t *testing.T) {
+	e := openLargeTestFile(t, 80000)
+	e.Scroll()
+
+	typeKeys(t, e, keyPageDown, keyPageDown, keyPageDown, keyPageDown, keyPageDown)
+	if want := 5 * e.TextRows(); e.CY != want {
+		t.Fatalf("CY = %d after paging down, want %d", e.CY, want)
+	}
+	if e.Rows[e.CY] == nil {
+		t.Fatal("cursor row not loaded after paging")
+	}
+
+	typeKeys(t, e, keyCtrlEnd, keyPageUp, keyPageUp)
+	if e.Rows[e.CY] == nil {
+		t.Fatal("cursor row not loaded after paging")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	lineEndingLF   = "\n"
+	lineEndingCRLF = "\r\n"
+)
+
+type lineEndingCounter struct {
+	lf         int
+	crlf       int
+	unfinished bool
+}
+
+func (c *lineEndingCounter) Split(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		if i > 0 && data[i-1] == '\r' {
+			c.crlf++
+			return i + 1, data[:i-1], nil
+		}
+		c.lf++
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		c.unfinished = true
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func (c *lineEndingCounter) Dominant() string {
+	if c.crlf > c.lf {
+		return lineEndingCRLF
+	}
+	return lineEndingLF
+}
+
+func (c *lineEndingCounter) Mixed() bool {
+	return c.lf > 0 && c.crlf > 0
+}
+
+func (e *Editor) EffectiveLineEnding() string {
+	switch strings.ToLower(e.Config.LineEnding) {
+	case "lf":
+		return lineEndingLF
+	case "crlf":
+		return lineEndingCRLF
+	}
+
+	if e.LineEnding == "" {
+		return lineEndingLF
+	}
+	return e.LineEnding
+}
+
+func lineEndingName(ending string) string {
+	if ending == lineEndingCRLF {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+func (e *Editor) NormalizeLineEndings() error {
+	if !e.Writable() {
+		return nil
+	}
+
+	choice, err := e.Prompt("Normalize line endings to: %s (lf/crlf, ESC to cancel)", nil)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(choice)) {
+	case "lf":
+		e.LineEnding = lineEndingLF
+	case "crlf":
+		e.LineEnding = lineEndingCRLF
+	default:
+		e.SetStatusMessage("Unknown line ending %q", choice)
+		return nil
+	}
+
+	e.MixedLineEndings = false
+	e.Dirty++
+	e.SetStatusMessage("Line endings normalized to %s", lineEndingName(e.LineEnding))
+	return nil
+}
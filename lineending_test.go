@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func typeString(t *testing.T, e *Editor, s string, keys ...key) {
+	t.Helper()
+	for _, r := range s {
+		e.pendingKeys = append(e.pendingKeys, key(r))
+	}
+	typeKeys(t, e, keys...)
+}
+
+func TestMixedLineEndingsWarnAndNormalize(t *testing.T) {
+	silenceStdout(t)
+	e := openTestFile(t, "mixed.txt", "one\r\ntwo\nthree\r\nfour\r\n")
+
+	if !e.MixedLineEndings || e.LineEnding != lineEndingCRLF {
+		t.Fatalf("Mixed = %v, LineEnding = %q", e.MixedLineEndings, e.LineEnding)
+	}
+	if !strings.Contains(e.StatusMessage, "Mixed line endings (1 LF, 3 CRLF)") {
+		t.Fatalf("status = %q", e.StatusMessage)
+	}
+	if len(e.Rows) != 4 || string(e.Rows[0].chars) != "one" {
+		t.Fatalf("%d rows, first %q", len(e.Rows), string(e.Rows[0].chars))
+	}
+
+	e.pendingKeys = append(e.pendingKeys, keyFor(t, "normalize-line-endings"))
+	typeString(t, e, "lf", keyEnter)
+	if e.MixedLineEndings || e.LineEnding != lineEndingLF || e.Dirty == 0 {
+		t.Fatalf("after normalize: Mixed = %v, LineEnding = %q, Dirty = %d", e.MixedLineEndings, e.LineEnding, e.Dirty)
+	}
+
+	typeKeys(t, e, keyFor(t, "save"))
+	data, err := os.ReadFile(e.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one\ntwo\nthree\nfour\n" {
+		t.Fatalf("saved %q", data)
+	}
+}
+
+func TestUniformLineEndingsNoWarning(t *testing.T) {
+	e := openTestFile(t, "crlf.txt", "one\r\ntwo\r\n")
+	if e.MixedLineEndings || e.LineEnding != lineEndingCRLF {
+		t.Fatalf("Mixed = %v, LineEnding = %q", e.MixedLineEndings, e.LineEnding)
+	}
+	if strings.Contains(e.StatusMessage, "Mixed") {
+		t.Fatalf("status = %q", e.StatusMessage)
+	}
+}
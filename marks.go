@@ -0,0 +1,72 @@
+package main
+
+import "unicode"
+
+func (e *Editor) readMarkName(prompt string) (rune, bool, error) {
+	e.SetStatusMessage(prompt)
+	e.Render()
+
+	k, err := e.WaitKey()
+	if err != nil {
+		return 0, false, err
+	}
+	if k >= keyArrowLeft || !unicode.IsLetter(rune(k)) {
+		e.SetStatusMessage("")
+		return 0, false, nil
+	}
+	return rune(k), true, nil
+}
+
+func (e *Editor) SetMark() error {
+	name, ok, err := e.readMarkName("Set mark: press a letter (ESC to cancel)")
+	if err != nil || !ok {
+		return err
+	}
+
+	if e.marks == nil {
+		e.marks = make(map[rune]cursor)
+	}
+	e.marks[name] = cursor{e.CX, e.CY}
+	e.SetStatusMessage("Mark %c set at line %d", name, e.CY+1)
+	return nil
+}
+
+func (e *Editor) JumpToMark() error {
+	name, ok, err := e.readMarkName("Jump to mark: press a letter (ESC to cancel)")
+	if err != nil || !ok {
+		return err
+	}
+	e.GotoMark(name)
+	return nil
+}
+
+func (e *Editor) GotoMark(name rune) {
+	m, ok := e.marks[name]
+	if !ok {
+		e.SetStatusMessage("Mark %c is not set", name)
+		return
+	}
+
+	e.RecordJump(e.CX, e.CY)
+	e.CY = m.y
+	if e.CY > len(e.Rows) {
+		e.CY = len(e.Rows)
+	}
+	e.CX = m.x
+	if e.CY == len(e.Rows) {
+		e.CX = 0
+	} else if linelen := len(e.RowAt(e.CY).chars); e.CX > linelen {
+		e.CX = linelen
+	}
+	e.Scroll()
+	e.SetStatusMessage("Jumped to mark %c", name)
+}
+
+func (e *Editor) shiftMarks(at, delta int) {
+	for name, m := range e.marks {
+		if m.y > at || (delta > 0 && m.y == at) {
+			m.y += delta
+			e.marks[name] = m
+		}
+	}
+}
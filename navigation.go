@@ -0,0 +1,193 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func (e *Editor) GotoLine() error {
+	input, err := e.Prompt("Go to line: %s (+N/-N = Relative, N%% = Percentage, ESC to cancel)", nil)
+	if err != nil {
+		return err
+	}
+
+	input = strings.TrimSpace(input)
+	relative := strings.HasPrefix(input, "+") || strings.HasPrefix(input, "-")
+	percent := strings.HasSuffix(input, "%")
+
+	n, err := strconv.Atoi(strings.TrimSuffix(input, "%"))
+	if err != nil || (percent && relative) {
+		e.SetStatusMessage("Invalid line number")
+		return nil
+	}
+
+	line := n - 1
+	if relative {
+		line = e.CY + n
+	}
+	if percent {
+		line = len(e.Rows) * n / 100
+	}
+
+	if line > len(e.Rows)-1 {
+		line = len(e.Rows) - 1
+	}
+	if line < 0 {
+		line = 0
+	}
+
+	e.RecordJump(e.CX, e.CY)
+	e.CY = line
+	e.CX = 0
+	if percent && e.CY < len(e.Rows) {
+		e.CX = len(LeadingWhitespace(e.RowAt(e.CY).chars))
+	}
+	e.Scroll()
+	return nil
+}
+
+func wordRight(chars []rune, cx int) int {
+	for cx < len(chars) && IsSeparator(chars[cx]) {
+		cx++
+	}
+	for cx < len(chars) && !IsSeparator(chars[cx]) {
+		cx++
+	}
+	return cx
+}
+
+func wordLeft(chars []rune, cx int) int {
+	for cx > 0 && IsSeparator(chars[cx-1]) {
+		cx--
+	}
+	for cx > 0 && !IsSeparator(chars[cx-1]) {
+		cx--
+	}
+	return cx
+}
+
+func (e *Editor) MoveWordRight() {
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	chars := e.Rows[e.CY].chars
+	if e.CX >= len(chars) {
+		e.MoveCursor(keyArrowRight)
+		return
+	}
+	e.CX = wordRight(chars, e.CX)
+}
+
+func (e *Editor) MoveWordLeft() {
+	if e.CX == 0 || e.CY >= len(e.Rows) {
+		e.MoveCursor(keyArrowLeft)
+		return
+	}
+	e.CX = wordLeft(e.Rows[e.CY].chars, e.CX)
+}
+
+func (e *Editor) DeleteWordLeft() {
+	if !e.Writable() {
+		return
+	}
+
+	if e.CX == 0 || e.CY >= len(e.Rows) {
+		e.DeleteChar()
+		return
+	}
+
+	start := wordLeft(e.Rows[e.CY].chars, e.CX)
+	e.DeleteRunes(e.CY, start, e.CX-start)
+	e.CX = start
+	e.Dirty++
+}
+
+func (e *Editor) DeleteWordRight() {
+	if !e.Writable() {
+		return
+	}
+
+	if e.CY >= len(e.Rows) {
+		return
+	}
+
+	chars := e.Rows[e.CY].chars
+	if e.CX >= len(chars) {
+		if e.CY < len(e.Rows)-1 {
+			e.MoveCursor(keyArrowRight)
+			e.DeleteChar()
+		}
+		return
+	}
+
+	end := wordRight(chars, e.CX)
+	e.DeleteRunes(e.CY, e.CX, end-e.CX)
+	e.Dirty++
+}
+
+func (e *Editor) MoveTop() {
+	e.CY, e.CX = 0, 0
+	e.Scroll()
+}
+
+func (e *Editor) MoveBottom() {
+	e.CY, e.CX = 0, 0
+	if len(e.Rows) > 0 {
+		e.CY = len(e.Rows) - 1
+		e.CX = len(e.RowAt(e.CY).chars)
+	}
+	e.Scroll()
+}
+
+func (e *Editor) MovePage(dir int) {
+	if e.pageRX < 0 {
+		e.pageRX = 0
+		if e.CY < len(e.Rows) {
+			e.pageRX = e.RowCxToRx(e.RowAt(e.CY), e.CX)
+		}
+	}
+
+	e.CY += dir * e.TextRows()
+	e.RowOffset += dir * e.TextRows()
+	if e.CY < 0 {
+		e.CY = 0
+	}
+	if e.CY > len(e.Rows) {
+		e.CY = len(e.Rows)
+	}
+	e.skipFolded(dir)
+	if e.RowOffset < 0 {
+		e.RowOffset = 0
+	}
+	if e.RowOffset > e.CY {
+		e.RowOffset = e.CY
+	}
+
+	e.LoadRows()
+
+	e.CX = 0
+	if e.CY < len(e.Rows) {
+		e.CX = e.RowRxToCx(e.Rows[e.CY], e.pageRX)
+	}
+}
+
+func (e *Editor) MoveHome() {
+	if e.CY >= len(e.Rows) {
+		e.CX = 0
+		return
+	}
+
+	chars := e.Rows[e.CY].chars
+	first := 0
+	for first < len(chars) && unicode.IsSpace(chars[first]) {
+		first++
+	}
+
+	if e.CX == first {
+		e.CX = 0
+	} else {
+		e.CX = first
+	}
+}
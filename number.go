@@ -0,0 +1,78 @@
+package main
+
+import "unicode"
+
+type NumberFormats struct {
+	Hex         bool `json:"hex"`
+	Binary      bool `json:"binary"`
+	Octal       bool `json:"octal"`
+	Underscores bool `json:"underscores"`
+	Exponent    bool `json:"exponent"`
+}
+
+func isDigitInBase(r rune, base int) bool {
+	switch base {
+	case 2:
+		return r == '0' || r == '1'
+	case 8:
+		return r >= '0' && r <= '7'
+	case 16:
+		return unicode.Is(unicode.ASCII_Hex_Digit, r)
+	}
+	return r >= '0' && r <= '9'
+}
+
+func ScanNumber(runes []rune, n NumberFormats) int {
+	i := 0
+	digits := func(base int) int {
+		start := i
+		for i < len(runes) && (isDigitInBase(runes[i], base) || n.Underscores && runes[i] == '_' && i > start) {
+			i++
+		}
+		return i - start
+	}
+
+	if len(runes) > 2 && runes[0] == '0' {
+		base := 0
+		switch unicode.ToLower(runes[1]) {
+		case 'x':
+			if n.Hex {
+				base = 16
+			}
+		case 'b':
+			if n.Binary {
+				base = 2
+			}
+		case 'o':
+			if n.Octal {
+				base = 8
+			}
+		}
+		if base != 0 {
+			i = 2
+			if digits(base) > 0 {
+				return i
+			}
+			i = 0
+		}
+	}
+
+	digits(10)
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		digits(10)
+	}
+
+	if n.Exponent && i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		if j < len(runes) && isDigitInBase(runes[j], 10) {
+			i = j
+			digits(10)
+		}
+	}
+
+	return i
+}
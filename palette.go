@@ -0,0 +1,33 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+func (e *Editor) CommandPalette() (string, error) {
+	var actions []string
+	for action := range defaultKeymap {
+		if action != "command-palette" {
+			actions = append(actions, action)
+		}
+	}
+	sort.Strings(actions)
+
+	e.Action(0)
+	bound := map[string][]string{}
+	for k, action := range e.Keymap {
+		if name := keyName(k); name != "" {
+			bound[action] = append(bound[action], name)
+		}
+	}
+
+	return e.FuzzyPrompt("Command: %s (Up/Down = Select | Enter = Run | ESC = Cancel)", actions, func(action string) string {
+		names := bound[action]
+		if len(names) == 0 {
+			return action
+		}
+		sort.Strings(names)
+		return action + "  (" + strings.Join(names, ", ") + ")"
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"os/user"
+	"strings"
+)
+
+func ExpandPath(path string) string {
+	if strings.HasPrefix(path, "~") {
+		name, rest := path[1:], ""
+		if i := strings.Index(path, "/"); i >= 0 {
+			name, rest = path[1:i], path[i:]
+		}
+
+		var home string
+		if name == "" {
+			home, _ = os.UserHomeDir()
+		} else if u, err := user.Lookup(name); err == nil {
+			home = u.HomeDir
+		}
+		if home != "" {
+			path = home + rest
+		}
+	}
+
+	return os.Expand(path, func(name string) string {
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return "$" + name
+	})
+}
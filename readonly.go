@@ -0,0 +1,29 @@
+package main
+
+import "errors"
+
+var ErrReadOnly = errors.New("buffer is read-only")
+
+// Writable reports whether the buffer may be changed, telling the user why
+// not when it is read-only. Every editing command checks it first.
+func (e *Editor) Writable() bool {
+	if e.ReadOnly {
+		e.SetStatusMessage("Buffer is read-only")
+		return false
+	}
+	return true
+}
+
+func (e *Editor) ToggleReadOnly() {
+	if e.lazy != nil {
+		e.SetStatusMessage("Large files can only be viewed")
+		return
+	}
+
+	e.ReadOnly = !e.ReadOnly
+	if e.ReadOnly {
+		e.SetStatusMessage("Buffer is now read-only")
+	} else {
+		e.SetStatusMessage("Buffer is now writable")
+	}
+}
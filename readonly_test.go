@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func keyFor(t *testing.T, action string) key {
+	t.Helper()
+	k, err := parseKeySpec(defaultKeymap[action][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestReadOnlyRefusesEdits(t *testing.T) {
+	silenceStdout(t)
+	actions := []string{
+		"newline", "backspace", "delete", "delete-line", "cut", "paste", "undo",
+		"delete-word-left", "delete-word-right", "move-line-up", "move-line-down", "tab", "dedent",
+		"duplicate-line", "join-lines", "transpose", "upper-case", "insert-date", "complete",
+	}
+
+	for _, action := range actions {
+		e := newTestEditor("first line", "second line", "third line")
+		e.Clipboard = "pasted"
+		e.CY, e.CX = 1, 3
+		typeKeys(t, e, key('x'), keyEnter)
+		e.ReadOnly = true
+		e.SetStatusMessage("")
+
+		typeKeys(t, e, keyFor(t, action))
+		if got := e.RowsToString(); got != "first line\nsecx\nond line\nthird line\n" {
+			t.Errorf("%s changed a read-only buffer:\n%s", action, got)
+		}
+		if e.StatusMessage != "Buffer is read-only" {
+			t.Errorf("%s: status = %q", action, e.StatusMessage)
+		}
+	}
+}
+
+func TestReadOnlyRefusesTypingAndSave(t *testing.T) {
+	e := newTestEditor("text")
+	e.ReadOnly = true
+
+	e.InsertChar('x')
+	e.InsertNewline()
+	e.DeleteRow(0)
+	if got := e.RowsToString(); got != "text\n" || e.Dirty != 0 {
+		t.Fatalf("buffer = %q, Dirty = %d", got, e.Dirty)
+	}
+	if _, err := e.Save(); err != ErrReadOnly {
+		t.Fatalf("Save err = %v, want ErrReadOnly", err)
+	}
+}
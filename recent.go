@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+)
+
+const RECENT_FILES = ".config/cookie/recent.json"
+const maxRecentFiles = 30
+
+func loadRecentFiles() []string {
+	path, err := homePath(RECENT_FILES)
+	if err != nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var recent []string
+	if err := json.Unmarshal(data, &recent); err != nil {
+		Debugf("recent files list is corrupt: %v", err)
+		return nil
+	}
+	return recent
+}
+
+func (e *Editor) AddRecentFile() {
+	if e.Filename == "" {
+		return
+	}
+
+	filename := e.absFilename()
+	recent := []string{filename}
+	for _, f := range loadRecentFiles() {
+		if f != filename && len(recent) < maxRecentFiles {
+			recent = append(recent, f)
+		}
+	}
+
+	path, err := homePath(RECENT_FILES)
+	if err != nil {
+		return
+	}
+
+	data, err := json.Marshal(recent)
+	if err != nil {
+		return
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		Debugf("save recent files failed: %v", err)
+	}
+}
+
+func (e *Editor) OpenRecent() error {
+	recent := loadRecentFiles()
+	if len(recent) == 0 {
+		e.SetStatusMessage("No recent files")
+		return nil
+	}
+
+	i := 0
+	if e.Filename != "" && recent[0] == e.absFilename() && len(recent) > 1 {
+		i = 1
+	}
+
+	for {
+		e.SetStatusMessage("Open recent [%d/%d]: %s (Up/Down = Cycle | Enter = Open | ESC = Cancel)", i+1, len(recent), recent[i])
+		e.Render()
+
+		k, err := e.WaitKey()
+		if err != nil {
+			return err
+		}
+
+		switch k {
+		case keyArrowUp, keyArrowLeft:
+			i = (i + len(recent) - 1) % len(recent)
+		case keyArrowDown, keyArrowRight, key('\t'):
+			i = (i + 1) % len(recent)
+		case keyEnter:
+			return e.OpenInNewBuffer(recent[i])
+		case key('\x1b'):
+			return ErrPromptCanceled
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+func (e *Editor) Replace() error {
+	if !e.Writable() {
+		return nil
+	}
+
+	query, err := e.Prompt("Replace: %s (ESC to cancel)", nil)
+	if err != nil {
+		return err
+	}
+
+	replacement, err := e.PromptOptional("Replace \"" + strings.ReplaceAll(query, "%", "%%") + "\" with: %s (ESC to cancel)")
+	if err != nil {
+		return err
+	}
+
+	queryLen := utf8.RuneCountInString(query)
+	with := []rune(replacement)
+	replaced := 0
+	all := false
+
+	y, x := e.CY, e.CX
+	for y < len(e.Rows) {
+		row := e.Rows[y]
+		if x > len(row.chars) {
+			x = len(row.chars)
+		}
+
+		idx := strings.Index(string(row.chars[x:]), query)
+		if idx == -1 {
+			y++
+			x = 0
+			continue
+		}
+		x += utf8.RuneCountInString(string(row.chars[x:])[:idx])
+
+		e.CY, e.CX = y, x
+		if !all {
+			start := e.RowCxToRenderIdx(row, x)
+			end := e.RowCxToRenderIdx(row, x+queryLen)
+			for i := start; i < end && i < len(row.hl); i++ {
+				row.hl[i] = hlMatch
+			}
+
+			e.SetStatusMessage("Replace this match? (y = Yes | n = No | a = All | q = Quit)")
+			e.Render()
+			row.hlValid = false
+			e.UpdateHighlight(row)
+
+			k, err := e.WaitKey()
+			if err != nil {
+				return err
+			}
+
+			switch k {
+			case key('y'), key('Y'):
+			case key('a'), key('A'):
+				all = true
+			case key('n'), key('N'):
+				x += queryLen
+				continue
+			default:
+				e.SetStatusMessage("Replaced %d occurrences", replaced)
+				return nil
+			}
+		}
+
+		e.DeleteRunes(y, x, queryLen)
+		e.InsertRunes(y, x, with)
+		e.Dirty++
+		replaced++
+
+		x += len(with)
+		e.CX = x
+	}
+
+	e.SetStatusMessage("Replaced %d occurrences", replaced)
+	return nil
+}
@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestReplaceWithEmptyString(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor("foo, bar, foo", "foofoo")
+	typeKeys(t, e, keyFor(t, "replace"), 'f', 'o', 'o', keyEnter, keyEnter, 'a')
+	if got := rowText(e); got != ", bar, \n" {
+		t.Fatalf("rows = %q", got)
+	}
+	if e.Dirty == 0 {
+		t.Fatal("buffer not marked dirty")
+	}
+}
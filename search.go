@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+type searchMatch struct {
+	rx, length int
+}
+
+type searchMatcher func(s string) []searchMatch
+
+func newSearchMatcher(query string, regex, ignoreCase bool) (searchMatcher, error) {
+	if regex {
+		if ignoreCase {
+			query = "(?i)" + query
+		}
+		re, err := regexp.Compile(query)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(s string) []searchMatch {
+			return runeMatches(s, re.FindAllStringIndex(s, -1))
+		}, nil
+	}
+
+	if ignoreCase {
+		query = strings.Map(unicode.ToLower, query)
+	}
+
+	return func(s string) []searchMatch {
+		if query == "" {
+			return nil
+		}
+		if ignoreCase {
+			s = strings.Map(unicode.ToLower, s)
+		}
+
+		var locs [][]int
+		for pos := 0; ; {
+			idx := strings.Index(s[pos:], query)
+			if idx == -1 {
+				break
+			}
+			locs = append(locs, []int{pos + idx, pos + idx + len(query)})
+			pos += idx + len(query)
+		}
+		return runeMatches(s, locs)
+	}, nil
+}
+
+// runeMatches converts byte offsets into s to rune offsets.
+func runeMatches(s string, locs [][]int) []searchMatch {
+	matches := make([]searchMatch, 0, len(locs))
+	pos, rx := 0, 0
+	for _, loc := range locs {
+		rx += utf8.RuneCountInString(s[pos:loc[0]])
+		length := utf8.RuneCountInString(s[loc[0]:loc[1]])
+		matches = append(matches, searchMatch{rx, length})
+		rx += length
+		pos = loc[1]
+	}
+	return matches
+}
+
+func (e *Editor) Find() error {
+	savedCx := e.CX
+	savedCy := e.CY
+	savedColOffset := e.ColOffset
+	savedRowOffset := e.RowOffset
+
+	lastMatchRowIndex, lastMatch := -1, 0
+	searchDirection := 1
+
+	savedHl := map[int][]uint8{}
+	saveHl := func(row *Row) {
+		if _, ok := savedHl[row.idx]; !ok {
+			savedHl[row.idx] = append([]uint8(nil), row.hl...)
+		}
+	}
+
+	regex := e.Config.RegexSearch
+	var matchErr error
+
+	var matchCounts []int
+	totalMatches, matchIndex := 0, 0
+	lastQuery := ""
+
+	label := func() string {
+		mode := ""
+		if regex {
+			mode = " [regex]"
+		}
+		if e.SearchIgnoreCase {
+			mode += " [ignore case]"
+		}
+		if matchErr != nil {
+			mode += " \x1b[31m" + strings.ReplaceAll(matchErr.Error(), "%", "%%") + "\x1b[0m"
+		} else if lastQuery != "" && totalMatches == 0 {
+			mode += " [no matches]"
+		} else if totalMatches > 0 {
+			mode += fmt.Sprintf(" [match %d of %d]", matchIndex, totalMatches)
+		}
+		return "Search" + mode + ": %s (ESC = Cancel | Enter = Confirm | Arrows = Prev/Next | Ctrl-R = Regex | Alt-C = Case)"
+	}
+
+	onKeyPress := func(query string, k key) {
+		for i, hl := range savedHl {
+			if e.Rows[i] != nil {
+				copy(e.Rows[i].hl, hl)
+			}
+		}
+		savedHl = map[int][]uint8{}
+
+		switch k {
+		case keyEnter, key('\x1b'):
+			lastMatchRowIndex = -1
+			searchDirection = 1
+			return
+		case keyArrowRight, keyArrowDown:
+			searchDirection = 1
+		case keyArrowLeft, keyArrowUp:
+			searchDirection = -1
+		case key(ctrl('r')):
+			regex = !regex
+			lastMatchRowIndex = -1
+			searchDirection = 1
+		case alt('c'):
+			e.SearchIgnoreCase = !e.SearchIgnoreCase
+			lastMatchRowIndex = -1
+			searchDirection = 1
+		default:
+
+			lastMatchRowIndex = -1
+			searchDirection = 1
+		}
+
+		if lastMatchRowIndex == -1 {
+			searchDirection = 1
+		}
+
+		lastQuery = query
+		if query == "" {
+			totalMatches, matchIndex = 0, 0
+			lastMatchRowIndex = -1
+			e.CX = savedCx
+			e.CY = savedCy
+			e.ColOffset = savedColOffset
+			e.RowOffset = savedRowOffset
+			return
+		}
+
+		match, err := newSearchMatcher(query, regex, e.SearchIgnoreCase)
+		matchErr = err
+		if err != nil {
+			totalMatches, matchIndex = 0, 0
+			return
+		}
+
+		if matchCounts == nil || lastMatchRowIndex == -1 {
+			matchCounts = e.countMatches(match)
+			totalMatches = 0
+			for _, n := range matchCounts {
+				totalMatches += n
+			}
+		}
+		if totalMatches == 0 {
+			matchIndex = 0
+			return
+		}
+
+		y, idx, matches := e.nextMatch(match, lastMatchRowIndex, lastMatch, searchDirection)
+		if y == -1 {
+			return
+		}
+
+		row := e.RowAt(y)
+		if e.lazy != nil {
+			row = e.LoadRow(y)
+		}
+		rx, length := matches[idx].rx, matches[idx].length
+		lastMatchRowIndex, lastMatch = y, idx
+		matchIndex = idx + 1
+		for _, n := range matchCounts[:y] {
+			matchIndex += n
+		}
+		e.CY = y
+		e.CX = e.RowRxToCx(row, runewidth.StringWidth(UTF8Slice(row.render, 0, rx)))
+
+		e.RowOffset = len(e.Rows)
+		e.Scroll()
+
+		for y := e.RowOffset; y < e.RowOffset+e.TextRows() && y < len(e.Rows); y++ {
+			e.highlightAllMatches(e.Rows[y], match, saveHl)
+		}
+
+		saveHl(row)
+		for i := 0; i < length; i++ {
+			row.hl[rx+i] = hlMatch
+		}
+	}
+
+	_, err := e.PromptLabel(label, onKeyPress)
+	if err == ErrPromptCanceled {
+		e.CX = savedCx
+		e.CY = savedCy
+		e.ColOffset = savedColOffset
+		e.RowOffset = savedRowOffset
+	} else if err == nil && (e.CX != savedCx || e.CY != savedCy) {
+		e.RecordJump(savedCx, savedCy)
+	}
+	return err
+}
+
+// nextMatch returns the match after match idx on row y, or before it when
+// dir is -1, wrapping around the buffer. y is -1 if nothing matches.
+func (e *Editor) nextMatch(match searchMatcher, y, idx, dir int) (int, int, []searchMatch) {
+	if y != -1 {
+		if matches := match(e.RowAt(y).render); idx+dir >= 0 && idx+dir < len(matches) {
+			return y, idx + dir, matches
+		}
+	}
+
+	for i := 0; i < len(e.Rows); i++ {
+		y += dir
+		switch y {
+		case -1:
+			y = len(e.Rows) - 1
+		case len(e.Rows):
+			y = 0
+		}
+
+		if matches := match(e.RowAt(y).render); len(matches) > 0 {
+			if dir < 0 {
+				return y, len(matches) - 1, matches
+			}
+			return y, 0, matches
+		}
+	}
+	return -1, 0, nil
+}
+
+func eachMatch(s string, match searchMatcher, fn func(rx, length int)) {
+	for _, m := range match(s) {
+		length := m.length
+		if length == 0 {
+			length = 1
+		}
+		fn(m.rx, length)
+	}
+}
+
+func (e *Editor) highlightAllMatches(row *Row, match searchMatcher, save func(*Row)) {
+	eachMatch(row.render, match, func(rx, length int) {
+		save(row)
+		for i := rx; i < rx+length && i < len(row.hl); i++ {
+			row.hl[i] = hlSearchAll
+		}
+	})
+}
+
+func (e *Editor) countMatches(match searchMatcher) []int {
+	counts := make([]int, len(e.Rows))
+	for i := range e.Rows {
+		eachMatch(e.RowAt(i).render, match, func(int, int) {
+			counts[i]++
+		})
+	}
+	return counts
+}
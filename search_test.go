@@ -0,0 +1,100 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchMatcherFindsAllMatches(t *testing.T) {
+	tests := []struct {
+		query      string
+		regex      bool
+		ignoreCase bool
+		line       string
+		want       []searchMatch
+	}{
+		{"a", false, false, "aaa", []searchMatch{{0, 1}, {1, 1}, {2, 1}}},
+		{"^a", true, false, "aaa", []searchMatch{{0, 1}}},
+		{"a$", true, false, "aaa", []searchMatch{{2, 1}}},
+		{"\\ba", true, false, "a aa", []searchMatch{{0, 1}, {2, 1}}},
+		{"llo", false, false, "héllo wörld héllo", []searchMatch{{2, 3}, {14, 3}}},
+		{"ö", true, false, "wörld wörld", []searchMatch{{1, 1}, {7, 1}}},
+		{"HÉ", false, true, "héllo Héllo", []searchMatch{{0, 2}, {6, 2}}},
+		{"x", false, false, "abc", []searchMatch{}},
+	}
+
+	for _, tt := range tests {
+		match, err := newSearchMatcher(tt.query, tt.regex, tt.ignoreCase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := match(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q in %q = %v, want %v", tt.query, tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestCountMatchesAnchored(t *testing.T) {
+	e := newTestEditor("aaa", "bab", "a")
+	match, err := newSearchMatcher("^a", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := e.countMatches(match); !reflect.DeepEqual(got, []int{1, 0, 1}) {
+		t.Fatalf("countMatches = %v, want [1 0 1]", got)
+	}
+}
+
+func TestNextMatchVisitsEveryMatch(t *testing.T) {
+	e := newTestEditor("foo foo", "bar", "foo")
+	match, err := newSearchMatcher("foo", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type pos struct{ y, idx, rx int }
+	var got []pos
+	y, idx := -1, 0
+	for i := 0; i < 4; i++ {
+		var matches []searchMatch
+		y, idx, matches = e.nextMatch(match, y, idx, 1)
+		got = append(got, pos{y, idx, matches[idx].rx})
+	}
+	want := []pos{{0, 0, 0}, {0, 1, 4}, {2, 0, 0}, {0, 0, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("forward = %v, want %v", got, want)
+	}
+
+	got = nil
+	for i := 0; i < 3; i++ {
+		var matches []searchMatch
+		y, idx, matches = e.nextMatch(match, y, idx, -1)
+		got = append(got, pos{y, idx, matches[idx].rx})
+	}
+	want = []pos{{2, 0, 0}, {0, 1, 4}, {0, 0, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("backward = %v, want %v", got, want)
+	}
+}
+
+func TestFindPromptCaseToggle(t *testing.T) {
+	silenceStdout(t)
+	e := newTestEditor("one", "Foo bar")
+
+	typeKeys(t, e, keyFor(t, "find"), 'f', 'o', 'o', '\t', keyEnter)
+	if e.SearchIgnoreCase {
+		t.Fatal("Tab toggled case sensitivity")
+	}
+	if e.CY != 0 {
+		t.Fatalf("case-sensitive search moved to row %d", e.CY)
+	}
+
+	typeKeys(t, e, keyFor(t, "find"), 'f', 'o', 'o', alt('c'), keyEnter)
+	if !e.SearchIgnoreCase {
+		t.Fatal("Alt-C did not toggle case sensitivity")
+	}
+	if e.CY != 1 || e.CX != 0 {
+		t.Fatalf("cursor = %d,%d, want 1,0", e.CY, e.CX)
+	}
+}
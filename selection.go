@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func isSelectionAction(action string) bool {
+	switch action {
+	case "up", "down", "left", "right",
+		"word-left", "word-right", "home", "end", "page-up", "page-down",
+		"top", "bottom", "dedent", "tab",
+		"toggle-selection", "copy", "word-count":
+		return true
+	}
+	return false
+}
+
+func (e *Editor) ToggleSelection() {
+	if e.Selecting {
+		e.ClearSelection()
+		e.SetStatusMessage("Selection cleared")
+		return
+	}
+
+	e.Selecting = true
+	e.SelAnchorX = e.CX
+	e.SelAnchorY = e.CY
+	e.SetStatusMessage("Selection started")
+}
+
+func (e *Editor) ClearSelection() {
+	e.Selecting = false
+}
+
+func (e *Editor) SelectionBounds() (sy, sx, ey, ex int, ok bool) {
+	if !e.Selecting {
+		return 0, 0, 0, 0, false
+	}
+
+	sy, sx, ey, ex = e.SelAnchorY, e.SelAnchorX, e.CY, e.CX
+	if sy > ey || (sy == ey && sx > ex) {
+		sy, sx, ey, ex = ey, ex, sy, sx
+	}
+
+	if sy >= len(e.Rows) {
+		return 0, 0, 0, 0, false
+	}
+	if ey >= len(e.Rows) {
+		ey = len(e.Rows) - 1
+		ex = len(e.RowAt(ey).chars)
+	}
+	if sx > len(e.RowAt(sy).chars) {
+		sx = len(e.RowAt(sy).chars)
+	}
+	if ex > len(e.RowAt(ey).chars) {
+		ex = len(e.RowAt(ey).chars)
+	}
+
+	return sy, sx, ey, ex, sy != ey || sx != ex
+}
+
+func (e *Editor) RowCxToRenderIdx(row *Row, cx int) int {
+	idx, col := 0, 0
+	for _, r := range row.chars[:cx] {
+		if r == '\t' {
+			n := e.TabStop() - (col % e.TabStop())
+			idx += n
+			col += n
+		} else {
+			idx++
+			col += runewidth.RuneWidth(r)
+		}
+	}
+	return idx
+}
+
+func (e *Editor) RowSelection(row *Row) (start, end int, ok bool) {
+	sy, sx, ey, ex, ok := e.SelectionBounds()
+	if !ok || row.idx < sy || row.idx > ey {
+		return 0, 0, false
+	}
+
+	startCx, endCx := 0, len(row.chars)
+	if row.idx == sy {
+		startCx = sx
+	}
+	if row.idx == ey {
+		endCx = ex
+	}
+
+	return e.RowCxToRenderIdx(row, startCx), e.RowCxToRenderIdx(row, endCx), true
+}
+
+func (e *Editor) SelectedText() string {
+	sy, sx, ey, ex, ok := e.SelectionBounds()
+	if !ok {
+		return ""
+	}
+
+	if sy == ey {
+		return string(e.RowAt(sy).chars[sx:ex])
+	}
+
+	var b strings.Builder
+	b.WriteString(string(e.RowAt(sy).chars[sx:]))
+	for y := sy + 1; y < ey; y++ {
+		b.WriteRune('\n')
+		b.WriteString(string(e.RowAt(y).chars))
+	}
+	b.WriteRune('\n')
+	b.WriteString(string(e.RowAt(ey).chars[:ex]))
+	return b.String()
+}
+
+func (e *Editor) DeleteSelection() {
+	sy, sx, ey, ex, ok := e.SelectionBounds()
+	if !ok {
+		return
+	}
+
+	if sy == ey {
+		e.DeleteRunes(sy, sx, ex-sx)
+	} else {
+		tail := append([]rune(nil), e.Rows[ey].chars[ex:]...)
+		e.DeleteRunes(sy, sx, len(e.Rows[sy].chars)-sx)
+		e.InsertRunes(sy, sx, tail)
+		for y := ey; y > sy; y-- {
+			e.DeleteRow(y)
+		}
+	}
+
+	e.CX, e.CY = sx, sy
+	e.Dirty++
+	e.ClearSelection()
+}
+
+func (e *Editor) CopySelection() {
+	text := e.SelectedText()
+	if text == "" {
+		return
+	}
+
+	e.SetClipboard(text)
+	e.ClearSelection()
+	e.SetStatusMessage("Copied %d characters", len([]rune(text)))
+}
+
+func (e *Editor) CutSelection() {
+	if !e.Writable() {
+		return
+	}
+
+	text := e.SelectedText()
+	if text == "" {
+		return
+	}
+
+	e.SetClipboard(text)
+	e.DeleteSelection()
+	e.SetStatusMessage("Cut %d characters", len([]rune(text)))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const SESSION_FILE = ".config/cookie/session.json"
+
+type SessionFile struct {
+	Filename  string `json:"filename"`
+	CX        int    `json:"cx"`
+	CY        int    `json:"cy"`
+	RowOffset int    `json:"row_offset"`
+}
+
+type Session struct {
+	Files   []SessionFile `json:"files"`
+	Current int           `json:"current"`
+}
+
+func homePath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("failed to get home directory")
+	}
+	return homeDir + "/" + name, nil
+}
+
+func (e *Editor) sessionFile() (SessionFile, bool) {
+	if e.Filename == "" {
+		return SessionFile{}, false
+	}
+
+	return SessionFile{Filename: e.absFilename(), CX: e.CX, CY: e.CY, RowOffset: e.RowOffset}, true
+}
+
+func (e *Editor) SaveSession() error {
+	var session Session
+
+	current := e.Buffer
+	for _, buf := range e.Buffers {
+		e.Buffer = buf
+		if f, ok := e.sessionFile(); ok {
+			if buf == current {
+				session.Current = len(session.Files)
+			}
+			session.Files = append(session.Files, f)
+		}
+	}
+	e.Buffer = current
+
+	path, err := homePath(SESSION_FILE)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func (e *Editor) RestoreSession() error {
+	path, err := homePath(SESSION_FILE)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read session file: %v", err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return fmt.Errorf("failed to decode session file: %v", err)
+	}
+
+	missing, moved := 0, 0
+	for i, f := range session.Files {
+		if i > 0 {
+			e.NewBuffer()
+		}
+
+		if err := e.OpenFile(f.Filename); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			missing++
+		}
+		if e.RestoreCursor(f.CX, f.CY, f.RowOffset) {
+			moved++
+		}
+	}
+
+	if session.Current >= 0 && session.Current < len(e.Buffers) {
+		e.Buffer = e.Buffers[session.Current]
+	}
+
+	if missing > 0 || moved > 0 {
+		e.SetStatusMessage("\x1b[33;1mWARNING\x1b[0m %d file(s) from the last session no longer exist, %d changed since", missing, moved)
+	}
+	return nil
+}
+
+func (e *Editor) RestoreCursor(cx, cy, rowOffset int) bool {
+	clamped := false
+	if cy > len(e.Rows) {
+		cy = len(e.Rows)
+		clamped = true
+	}
+	if cy < 0 {
+		cy = 0
+	}
+
+	linelen := 0
+	if cy < len(e.Rows) {
+		linelen = len(e.RowAt(cy).chars)
+	}
+	if cx > linelen {
+		cx = linelen
+		clamped = true
+	}
+	if cx < 0 {
+		cx = 0
+	}
+
+	if rowOffset > cy || rowOffset < 0 {
+		rowOffset = cy
+	}
+
+	e.CX, e.CY, e.RowOffset = cx, cy, rowOffset
+	return clamped
+}
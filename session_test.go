@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTestSession(t *testing.T) Session {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(os.Getenv("HOME"), SESSION_FILE))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestSaveSessionListsOpenBuffers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".config/cookie"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	e := newTestEditor()
+	e.Filename = filepath.Join(dir, "a.txt")
+	for _, name := range []string{"b.txt", "c.txt"} {
+		e.NewBuffer()
+		e.Filename = filepath.Join(dir, name)
+	}
+	e.SelectBuffer(1)
+	e.CloseBuffer()
+
+	if err := e.SaveSession(); err != nil {
+		t.Fatal(err)
+	}
+	session := readTestSession(t)
+	if len(session.Files) != 2 {
+		t.Fatalf("session has %d files, want 2: %+v", len(session.Files), session.Files)
+	}
+	if got := filepath.Base(session.Files[0].Filename); got != "a.txt" {
+		t.Fatalf("first file = %s, want a.txt", got)
+	}
+	if got := filepath.Base(session.Files[session.Current].Filename); got != "c.txt" {
+		t.Fatalf("current file = %s, want c.txt", got)
+	}
+}
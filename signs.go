@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+type Sign struct {
+	Glyph rune
+	Color Color
+}
+
+type SignProvider interface {
+	HasSigns(e *Editor) bool
+	Sign(e *Editor, row *Row) (Sign, bool)
+}
+
+func (e *Editor) AddSignProvider(p SignProvider) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.signProviders = append(e.signProviders, p)
+}
+
+func (e *Editor) SignColumnWidth() int {
+	for _, p := range e.signProviders {
+		if p.HasSigns(e) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (e *Editor) DrawSign(b *strings.Builder, filerow int) {
+	if filerow >= 0 && filerow < len(e.Rows) && e.Rows[filerow] != nil {
+		for _, p := range e.signProviders {
+			if sign, ok := p.Sign(e, e.Rows[filerow]); ok {
+				b.WriteString(e.Fg(sign.Color) + string(sign.Glyph) + "\x1b[39m")
+				return
+			}
+		}
+	}
+	b.WriteByte(' ')
+}
+
+type bookmarkSigns struct{}
+
+func (bookmarkSigns) HasSigns(e *Editor) bool {
+	return len(e.bookmarks) > 0
+}
+
+func (bookmarkSigns) Sign(e *Editor, row *Row) (Sign, bool) {
+	return Sign{'*', e.Theme.Bookmark}, e.bookmarks[row.idx]
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+const SNIPPETS_FILE = ".config/cookie/snippets.json"
+
+func HandleSnippets() (map[string]map[string]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.New("failed to get home directory")
+	}
+
+	snippetsFile := homeDir + "/" + SNIPPETS_FILE
+	if _, err := os.Stat(snippetsFile); err != nil {
+		if err := os.MkdirAll(homeDir+"/.config/cookie", 0755); err != nil {
+			return nil, errors.New("failed to create config directory")
+		}
+
+		if err := ioutil.WriteFile(snippetsFile, []byte(startingSnippetsJson), 0644); err != nil {
+			return nil, errors.New("failed to create snippets file")
+		}
+	}
+
+	snippets := map[string]map[string]string{}
+
+	file, err := ioutil.ReadFile(snippetsFile)
+	if err != nil {
+		return nil, errors.New("failed to read snippets file")
+	}
+
+	if err := json.Unmarshal(file, &snippets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snippets file: %v", err)
+	}
+
+	return snippets, nil
+}
+
+func (e *Editor) lookupSnippet(trigger string) (string, bool) {
+	if e.Syntax != nil {
+		if body, ok := e.Snippets[e.Syntax.FileType][trigger]; ok {
+			return body, true
+		}
+	}
+	body, ok := e.Snippets["*"][trigger]
+	return body, ok
+}
+
+func parseSnippet(body string) ([]string, map[int]cursor) {
+	stops := map[int]cursor{}
+	var lines []string
+	for y, line := range strings.Split(body, "\n") {
+		runes := []rune(line)
+		var out []rune
+		for i := 0; i < len(runes); i++ {
+			if runes[i] == '$' && i+1 < len(runes) && runes[i+1] >= '0' && runes[i+1] <= '9' {
+				n := int(runes[i+1] - '0')
+				if _, ok := stops[n]; !ok {
+					stops[n] = cursor{len(out), y}
+				}
+				i++
+				continue
+			}
+			out = append(out, runes[i])
+		}
+		lines = append(lines, string(out))
+	}
+	return lines, stops
+}
+
+func (e *Editor) ExpandSnippet() bool {
+	if !e.Writable() {
+		return false
+	}
+
+	if e.CY >= len(e.Rows) || e.Rows[e.CY] == nil {
+		return false
+	}
+
+	chars := e.Rows[e.CY].chars
+	start := e.CX
+	for start > 0 && isWordRune(chars[start-1]) {
+		start--
+	}
+	if start == e.CX {
+		return false
+	}
+
+	body, ok := e.lookupSnippet(string(chars[start:e.CX]))
+	if !ok {
+		return false
+	}
+
+	lines, stops := parseSnippet(body)
+	indent := LeadingWhitespace(chars)
+	unit := "\t"
+	if e.Config.SoftTabs {
+		unit = strings.Repeat(" ", e.TabStop())
+	}
+
+	text := lines[0]
+	prefixes := []int{start}
+	for _, line := range lines[1:] {
+		tabs := len(line) - len(strings.TrimLeft(line, "\t"))
+		prefix := indent + strings.Repeat(unit, tabs)
+		text += "\n" + prefix + line[tabs:]
+		prefixes = append(prefixes, len([]rune(prefix))-tabs)
+	}
+
+	y := e.CY
+	e.DeleteRunes(y, start, e.CX-start)
+	e.CX = start
+	e.InsertText(text)
+
+	numbers := make([]int, 0, len(stops))
+	for n := range stops {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool {
+		if numbers[i] == 0 || numbers[j] == 0 {
+			return numbers[j] == 0 && numbers[i] != 0
+		}
+		return numbers[i] < numbers[j]
+	})
+
+	e.snippetStops = nil
+	e.snippetTop, e.snippetBottom = y, y+len(lines)-1
+	for _, n := range numbers {
+		s := stops[n]
+		e.snippetStops = append(e.snippetStops, cursor{s.x + prefixes[s.y], y + s.y})
+	}
+	e.NextSnippetStop()
+	return true
+}
+
+func (e *Editor) NextSnippetStop() bool {
+	if len(e.snippetStops) == 0 {
+		return false
+	}
+
+	stop := e.snippetStops[0]
+	e.snippetStops = e.snippetStops[1:]
+	if stop.y >= len(e.Rows) {
+		e.snippetStops = nil
+		return false
+	}
+	e.CY = stop.y
+	e.CX = stop.x
+	if e.CX > len(e.Rows[e.CY].chars) {
+		e.CX = len(e.Rows[e.CY].chars)
+	}
+	return true
+}
+
+func (e *Editor) shiftSnippetStops(y, at, delta int) {
+	for i := range e.snippetStops {
+		s := &e.snippetStops[i]
+		if s.y != y || s.x < at {
+			continue
+		}
+		s.x += delta
+		if s.x < at {
+			s.x = at
+		}
+	}
+}
+
+func (e *Editor) shiftSnippetRows(at, delta int) {
+	for i := range e.snippetStops {
+		if s := &e.snippetStops[i]; s.y > at || (delta > 0 && s.y == at) {
+			s.y += delta
+		}
+	}
+	if e.snippetTop > at || (delta > 0 && e.snippetTop == at) {
+		e.snippetTop += delta
+	}
+	if e.snippetBottom > at || (delta > 0 && e.snippetBottom >= at-1) {
+		e.snippetBottom += delta
+	}
+}
+
+// DropSnippetStops forgets the remaining tab stops once the cursor has
+// left the rows the snippet was expanded into.
+func (e *Editor) DropSnippetStops() {
+	if len(e.snippetStops) > 0 && (e.CY < e.snippetTop || e.CY > e.snippetBottom) {
+		e.snippetStops = nil
+	}
+}
+
+// SetSnippets installs snippets loaded by HandleSnippets. A broken snippets
+// file keeps the current snippets and is reported once in the status bar.
+func (e *Editor) SetSnippets(snippets map[string]map[string]string, err error) {
+	if err != nil {
+		if msg := err.Error(); msg != e.snippetsErr {
+			e.SetStatusMessage("Snippets not loaded: %s", msg)
+			e.snippetsErr = msg
+		}
+		return
+	}
+	e.Snippets, e.snippetsErr = snippets, ""
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func newSnippetEditor(lines ...string) *Editor {
+	e := newTestEditor(lines...)
+	e.Snippets = map[string]map[string]string{
+		"*": {"fn": "func $1($2) {\n\t$0\n}"},
+	}
+	return e
+}
+
+func TestSnippetStopsFollowTab(t *testing.T) {
+	e := newSnippetEditor("fn")
+	e.CX = 2
+
+	typeKeys(t, e, key('\t'), key('f'), key('\t'), key('\t'))
+	if got := string(e.Rows[0].chars); got != "func f() {" {
+		t.Fatalf("line = %q", got)
+	}
+	if e.CY != 1 || e.CX != 1 {
+		t.Fatalf("cursor = %d,%d, want 1,1 at $0", e.CY, e.CX)
+	}
+}
+
+func TestSnippetStopsDroppedWhenCursorLeaves(t *testing.T) {
+	e := newSnippetEditor("fn", "after")
+	e.CX = 2
+
+	typeKeys(t, e, key('\t'), keyArrowDown, keyArrowDown, keyArrowDown)
+	if e.CY != 3 {
+		t.Fatalf("cursor on line %d, want 3", e.CY)
+	}
+	if len(e.snippetStops) != 0 {
+		t.Fatalf("%d snippet stops left after leaving the snippet", len(e.snippetStops))
+	}
+
+	typeKeys(t, e, key('\t'))
+	if got := string(e.Rows[3].chars); got != "a\tfter" {
+		t.Fatalf("Tab jumped to a stale stop instead of indenting: %q", got)
+	}
+}
+
+func TestSnippetStopsSurviveNewlines(t *testing.T) {
+	e := newSnippetEditor("fn")
+	e.CX = 2
+
+	typeKeys(t, e, key('\t'), keyEnter)
+	if len(e.snippetStops) != 2 {
+		t.Fatalf("%d snippet stops left after a newline inside the snippet, want 2", len(e.snippetStops))
+	}
+	if e.snippetTop != 0 || e.snippetBottom != 3 {
+		t.Fatalf("snippet rows = %d-%d, want 0-3", e.snippetTop, e.snippetBottom)
+	}
+}
+
+func TestBrokenSnippetsReported(t *testing.T) {
+	e := newSnippetEditor()
+	e.SetSnippets(nil, errors.New("failed to unmarshal snippets file"))
+	if e.Snippets == nil {
+		t.Fatal("broken snippets file dropped the loaded snippets")
+	}
+	if e.StatusMessage != "Snippets not loaded: failed to unmarshal snippets file" {
+		t.Fatalf("status = %q", e.StatusMessage)
+	}
+
+	e.SetStatusMessage("")
+	e.SetSnippets(nil, errors.New("failed to unmarshal snippets file"))
+	if e.StatusMessage != "" {
+		t.Fatalf("same error reported twice: %q", e.StatusMessage)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type view struct {
+	cx, cy, rx           int
+	rowOffset, colOffset int
+}
+
+type window struct {
+	buf *Buffer
+	view
+}
+
+func (e *Editor) saveView() view {
+	return view{e.CX, e.CY, e.RX, e.RowOffset, e.ColOffset}
+}
+
+func (e *Editor) loadView(v view) {
+	e.CX, e.CY, e.RX, e.RowOffset, e.ColOffset = v.cx, v.cy, v.rx, v.rowOffset, v.colOffset
+	if e.lazy == nil && e.CY > len(e.Rows) {
+		e.CY = len(e.Rows)
+	}
+	if e.CY < len(e.Rows) && e.Rows[e.CY] != nil && e.CX > len(e.Rows[e.CY].chars) {
+		e.CX = len(e.Rows[e.CY].chars)
+	}
+}
+
+func (e *Editor) hasBuffer(buf *Buffer) bool {
+	for _, b := range e.Buffers {
+		if b == buf {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Editor) isSplit() bool {
+	return len(e.windows) > 0 && e.browser == nil
+}
+
+func (e *Editor) windowRect(i int) (x, y, w, h int) {
+	w, h = e.ScreenCols, e.ScreenRows-e.TabBarHeight()
+	if !e.isSplit() {
+		return 0, 0, w, h
+	}
+
+	if e.splitHorizontal {
+		top := (h+1)/2 - 1
+		if i == 0 {
+			return 0, 0, w, top
+		}
+		return 0, top + 1, w, h - top - 1
+	}
+
+	left := (w - 1) / 2
+	if i == 0 {
+		return 0, 0, left, h
+	}
+	return left + 1, 0, w - left - 1, h
+}
+
+func (e *Editor) WindowCols() int {
+	_, _, w, _ := e.windowRect(e.curWin)
+	return w
+}
+
+func (e *Editor) WindowRows() int {
+	_, _, _, h := e.windowRect(e.curWin)
+	return h
+}
+
+func (e *Editor) inWindow(i int, fn func()) {
+	if i == e.winIdx {
+		fn()
+		return
+	}
+
+	win := e.windows[i]
+	if !e.hasBuffer(win.buf) {
+		win.buf = e.Buffer
+	}
+
+	active, saved := e.Buffer, e.saveView()
+	e.Buffer, e.curWin = win.buf, i
+	e.loadView(win.view)
+	fn()
+	win.view = e.saveView()
+	e.Buffer, e.curWin = active, e.winIdx
+	e.loadView(saved)
+}
+
+func (e *Editor) Split(horizontal bool) {
+	e.splitHorizontal = horizontal
+	e.InvalidateScreen()
+	if len(e.windows) > 0 {
+		return
+	}
+
+	v := e.saveView()
+	e.windows = []*window{{e.Buffer, v}, {e.Buffer, v}}
+	e.winIdx, e.curWin = 0, 0
+	e.SetStatusMessage("Split (Alt-A = Switch window | Alt-Q = Close window)")
+}
+
+func (e *Editor) CloseWindow() {
+	if len(e.windows) == 0 {
+		e.SetStatusMessage("No split to close")
+		return
+	}
+
+	e.OtherWindow()
+	e.windows = nil
+	e.winIdx, e.curWin = 0, 0
+	e.InvalidateScreen()
+}
+
+func (e *Editor) OtherWindow() {
+	if len(e.windows) == 0 {
+		e.SetStatusMessage("No split to switch to")
+		return
+	}
+
+	current := e.windows[e.winIdx]
+	current.buf, current.view = e.Buffer, e.saveView()
+
+	e.winIdx = (e.winIdx + 1) % len(e.windows)
+	e.curWin = e.winIdx
+	next := e.windows[e.winIdx]
+	if !e.hasBuffer(next.buf) {
+		next.buf = e.Buffer
+	}
+	e.Buffer = next.buf
+	e.loadView(next.view)
+}
+
+func (e *Editor) DrawWindow(i int) []string {
+	var lines []string
+	e.inWindow(i, func() {
+		// Each window of a large file needs its own range of rows loaded.
+		if i != e.winIdx {
+			e.Scroll()
+		} else {
+			e.LoadRows()
+		}
+		var b strings.Builder
+		e.DrawRows(&b)
+		e.DrawStatusBar(&b)
+		lines = strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	})
+	return lines
+}
+
+func (e *Editor) DrawWindows(b *strings.Builder) {
+	if !e.isSplit() {
+		e.DrawRows(b)
+		e.DrawStatusBar(b)
+		return
+	}
+
+	panes := make([][]string, len(e.windows))
+	for i := range e.windows {
+		panes[i] = e.DrawWindow(i)
+	}
+	e.LoadRows()
+
+	if e.splitHorizontal {
+		for _, pane := range panes {
+			for _, line := range pane {
+				b.WriteString(line)
+				b.WriteString("\r\n")
+			}
+		}
+		return
+	}
+
+	x, _, _, _ := e.windowRect(1)
+	for y := range panes[0] {
+		b.WriteString(panes[0][y])
+		b.WriteString(fmt.Sprintf("\x1b[m\x1b[%dG", x))
+		b.WriteString(e.Fg(e.Theme.LineNumber))
+		b.WriteString("│\x1b[m")
+		b.WriteString(panes[1][y])
+		b.WriteString("\r\n")
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestSplitLargeFileWindowsFarApart(t *testing.T) {
+	silenceStdout(t)
+	e := openLargeTestFile(t, 80000)
+	e.Render()
+
+	e.Split(false)
+	e.OtherWindow()
+	e.CY, e.CX = 79999, 0
+	e.Render()
+
+	if e.Rows[e.CY] == nil {
+		t.Fatal("focused window's row unloaded after drawing the other window")
+	}
+
+	e.OtherWindow()
+	if e.CY != 0 {
+		t.Fatalf("first window at row %d, want 0", e.CY)
+	}
+	e.Render()
+	if e.Rows[0] == nil {
+		t.Fatal("focused window's row unloaded after drawing the other window")
+	}
+}
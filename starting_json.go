@@ -5,6 +5,43 @@ const startingConfigJson = `{
 	"tab_stop": 4,
 	"quit_times": 1,
 	"empty_line_char": "~",
+	"wide_char_boundary": "pad",
+	"idle_delay": 1000,
+	"sticky_col_offset": false,
+	"col_offset_margin": 8,
+	"debug_log": false,
+	"show_line_numbers": false,
+	"relative_line_numbers": false,
+	"regex_search": false,
+	"auto_indent": true,
+	"ensure_final_newline": true,
+	"line_ending": "auto",
+	"soft_tabs": false,
+	"keep_bom": true,
+	"use_osc52": false,
+	"show_tab_bar": true,
+	"highlight_current_line": false,
+	"read_only": false,
+	"auto_save_seconds": 0,
+	"large_file_mb": 100,
+	"true_color": false,
+	"show_column": true,
+	"show_percentage": true,
+	"cursor_shape": "block",
+	"scroll_off": 3,
+	"warn_mixed_indent": false,
+	"show_whitespace": false,
+	"word_wrap": false,
+	"color_column": [],
+	"electric_braces": true,
+	"git_signs": true,
+	"show_git_branch": true,
+	"highlight_trailing_whitespace": false,
+	"date_format": "2006-01-02 15:04",
+	"join_with_space": true,
+	"new_file_mode": "",
+	"max_undo_levels": 1000,
+	"max_undo_memory": 64,
 	"color_palette": {
 		"normal": 15,
 		"comment": 238,
@@ -14,10 +51,40 @@ const startingConfigJson = `{
 		"string": 14,
 		"number": 147,
 		"boolean": 6,
-		"match": 32
+		"match": 32,
+		"search_all": 24,
+		"line_number": 240,
+		"current_line": 236,
+		"mixed_indent": 52,
+		"color_column": 235,
+		"bookmark": 214,
+		"git_added": 34,
+		"git_modified": 178,
+		"git_removed": 160
 	}
 }`
 
+const startingThemeJson = `{
+	"normal": 15,
+	"comment": 238,
+	"multiline_comment": 238,
+	"keyword1": 105,
+	"keyword2": 141,
+	"string": 14,
+	"number": 147,
+	"boolean": 6,
+	"match": 32,
+	"search_all": 24,
+	"line_number": 240,
+	"current_line": 236,
+	"mixed_indent": 52,
+	"color_column": 235,
+	"bookmark": 214,
+	"git_added": 34,
+	"git_modified": 178,
+	"git_removed": 160
+}`
+
 const startingSyntaxJson = `[
     {
         "filetype":  "c",
@@ -33,6 +100,7 @@ const startingSyntaxJson = `[
         "scs": "//",
         "mcs": "/*",
         "mce": "*/",
+        "numbers": {"hex": true, "binary": true, "octal": false, "underscores": false, "exponent": true},
         "flags": {
             "highlight_numbers": true,
             "highlight_strings": true,
@@ -59,10 +127,13 @@ const startingSyntaxJson = `[
         "scs": "//",
         "mcs": "/*",
         "mce": "*/",
+        "mls": ["` + "`" + `"],
+        "numbers": {"hex": true, "binary": true, "octal": true, "underscores": true, "exponent": true},
         "flags": {
             "highlight_numbers": true,
             "highlight_strings": true,
-            "highlight_booleans": true
+            "highlight_booleans": true,
+            "highlight_multiline_strings": true
         }
     },
     {
@@ -78,10 +149,13 @@ const startingSyntaxJson = `[
         "scs": "#",
         "mcs": "#",
         "mce": "#",
+        "mls": ["\"\"\"", "'''"],
+        "numbers": {"hex": true, "binary": true, "octal": true, "underscores": true, "exponent": true},
         "flags": {
             "highlight_numbers": true,
             "highlight_strings": true,
-            "highlight_booleans": true
+            "highlight_booleans": true,
+            "highlight_multiline_strings": true
         }
     },
     {
@@ -101,6 +175,7 @@ const startingSyntaxJson = `[
         "scs": "//",
         "mcs": "/*",
         "mce": "*/",
+        "numbers": {"hex": true, "binary": true, "octal": false, "underscores": true, "exponent": true},
         "flags": {
             "highlight_numbers": true,
             "highlight_strings": true,
@@ -125,10 +200,13 @@ const startingSyntaxJson = `[
         "scs": "//",
         "mcs": "/*",
         "mce": "*/",
+        "mls": ["` + "`" + `"],
+        "numbers": {"hex": true, "binary": true, "octal": true, "underscores": true, "exponent": true},
         "flags": {
             "highlight_numbers": true,
             "highlight_strings": true,
-            "highlight_booleans": true
+            "highlight_booleans": true,
+            "highlight_multiline_strings": true
         }
     },
     {
@@ -149,10 +227,126 @@ const startingSyntaxJson = `[
         "scs": "//",
         "mcs": "/*",
         "mce": "*/",
+        "numbers": {"hex": true, "binary": true, "octal": true, "underscores": true, "exponent": true},
         "flags": {
             "highlight_numbers": true,
             "highlight_strings": true,
             "highlight_booleans": true
         }
+    },
+    {
+        "filetype": "makefile",
+        "filematch": ["Makefile", "makefile", "GNUmakefile", ".mk"],
+        "keywords": [
+            "ifeq", "ifneq", "ifdef", "ifndef", "else", "endif", "include",
+            "define", "endef", "export", "override",
+
+            ".PHONY|"
+        ],
+        "scs": "#",
+        "tab_stop": 8,
+        "flags": {
+            "highlight_numbers": false,
+            "highlight_strings": true,
+            "highlight_booleans": false
+        }
     }
 ]`
+
+const startingSnippetsJson = `{
+	"*": {
+		"todo": "TODO: $0"
+	},
+	"go": {
+		"iferr": "if err != nil {\n\treturn $1\n}$0",
+		"fn": "func $1($2) {\n\t$0\n}",
+		"for": "for $1 := range $2 {\n\t$0\n}"
+	},
+	"python": {
+		"def": "def $1($2):\n\t$0"
+	}
+}`
+
+const startingKeysJson = `{
+	"newline": "enter",
+	"quit": "ctrl-q",
+	"save": "ctrl-s",
+	"save-as": "ctrl-o",
+	"find": "ctrl-f",
+	"replace": "ctrl-r",
+	"goto-line": "ctrl-g",
+	"delete-line": "ctrl-d",
+	"toggle-selection": "ctrl-space",
+	"copy": "ctrl-c",
+	"cut": "ctrl-x",
+	"paste": "ctrl-v",
+	"undo": "ctrl-z",
+	"redo": "ctrl-y",
+	"normalize-line-endings": "ctrl-e",
+	"home": "home",
+	"end": "end",
+	"tab": "tab",
+	"dedent": "shift-tab",
+	"backspace": ["backspace", "ctrl-h"],
+	"delete": "delete",
+	"top": "ctrl-home",
+	"bottom": "ctrl-end",
+	"page-up": "page-up",
+	"page-down": "page-down",
+	"up": "up",
+	"down": "down",
+	"left": "left",
+	"right": "right",
+	"delete-word-left": "ctrl-w",
+	"delete-word-right": "ctrl-delete",
+	"word-left": "ctrl-left",
+	"word-right": "ctrl-right",
+	"move-line-up": "alt-up",
+	"move-line-down": "alt-down",
+	"redraw": "ctrl-l",
+	"escape": "esc",
+	"add-cursor": "alt-n",
+	"jump-back": "alt-left",
+	"jump-forward": "alt-right",
+	"set-mark": "alt-m",
+	"jump-to-mark": "alt-g",
+	"duplicate-line": "alt-d",
+	"open": "alt-o",
+	"open-recent": "alt-e",
+	"next-buffer": "ctrl-n",
+	"previous-buffer": "ctrl-p",
+	"buffer-1": "alt-1",
+	"buffer-2": "alt-2",
+	"buffer-3": "alt-3",
+	"buffer-4": "alt-4",
+	"buffer-5": "alt-5",
+	"buffer-6": "alt-6",
+	"buffer-7": "alt-7",
+	"buffer-8": "alt-8",
+	"buffer-9": "alt-9",
+	"fold": "alt-f",
+	"bookmark": "alt-b",
+	"complete": "alt-/",
+	"next-bookmark": "alt-.",
+	"previous-bookmark": "alt-,",
+	"toggle-read-only": "alt-r",
+	"reload-file": "alt-l",
+	"reload-config": "alt-c",
+	"convert-indentation": "alt-t",
+	"insert-date": "alt-i",
+	"join-lines": "alt-j",
+	"transpose": "ctrl-t",
+	"upper-case": "alt-u",
+	"lower-case": "alt-k",
+	"title-case": "alt-y",
+	"word-count": "alt-w",
+	"hex-mode": "alt-h",
+	"browse": "ctrl-b",
+	"command-palette": "alt-x",
+	"find-file": "alt-p",
+	"split-vertical": "alt-v",
+	"other-window": "alt-a",
+	"split-horizontal": "alt-s",
+	"close-window": "alt-q",
+	"undo-history": []
+}`
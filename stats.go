@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+func countWords(text string) int {
+	words, inWord := 0, false
+	for _, r := range text {
+		if IsSeparator(r) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+	return words
+}
+
+func (e *Editor) ShowStats() {
+	scope := "Buffer"
+	text := e.RowsToString()
+	lines := len(e.Rows)
+	if e.Selecting {
+		scope = "Selection"
+		text = e.SelectedText()
+		lines = strings.Count(text, "\n") + 1
+	}
+
+	e.SetStatusMessage("%s: %d lines, %d words, %d chars, %d bytes", scope, lines, countWords(text), utf8.RuneCountInString(text), len([]byte(text)))
+}
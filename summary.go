@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (e *Editor) recordSave(filename string, n int) {
+	e.bytesWritten += n
+	for _, saved := range e.savedFiles {
+		if saved == filename {
+			return
+		}
+	}
+	e.savedFiles = append(e.savedFiles, filename)
+}
+
+func (e *Editor) Summary() string {
+	summary := "cookie: no files saved"
+	if len(e.savedFiles) > 0 {
+		summary = fmt.Sprintf("cookie: saved %s (%d bytes written)", strings.Join(e.savedFiles, ", "), e.bytesWritten)
+	}
+	if len(e.discarded) > 0 {
+		summary += "; discarded unsaved changes in " + strings.Join(e.discarded, ", ")
+	}
+	return summary
+}
+
+func (e *Editor) ExitCode() int {
+	if len(e.discarded) > 0 {
+		return 1
+	}
+	return 0
+}
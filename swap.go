@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func swapPath(filename string) string {
+	dir, base := filepath.Split(filename)
+	return filepath.Join(dir, "."+base+".swp")
+}
+
+func (e *Editor) WriteSwap() {
+	if e.Filename == "" || e.Dirty == 0 || e.hex != nil {
+		return
+	}
+
+	if err := os.WriteFile(swapPath(e.Filename), []byte(e.RowsToString()), 0600); err != nil {
+		Debugf("swap %s failed: %v", e.Filename, err)
+	}
+}
+
+func (e *Editor) WriteSwapFiles() {
+	current := e.Buffer
+	for _, buf := range e.Buffers {
+		e.Buffer = buf
+		e.WriteSwap()
+	}
+	e.Buffer = current
+}
+
+func (e *Editor) RemoveSwap() {
+	if e.Filename == "" {
+		return
+	}
+
+	if err := os.Remove(swapPath(e.Filename)); err != nil && !os.IsNotExist(err) {
+		Debugf("remove swap %s failed: %v", e.Filename, err)
+	}
+}
+
+func (e *Editor) RecoverSwap() error {
+	swap := swapPath(e.Filename)
+	swapInfo, err := os.Stat(swap)
+	if err != nil {
+		return nil
+	}
+	if info, err := os.Stat(e.Filename); err == nil && !swapInfo.ModTime().After(info.ModTime()) {
+		return nil
+	}
+
+	ok, err := e.Confirm("Found swap file for %s. Recover unsaved changes?", e.DisplayName())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		e.RemoveSwap()
+		return nil
+	}
+
+	content, err := os.ReadFile(swap)
+	if err != nil {
+		e.SetStatusMessage("Can't read swap file: %s", err.Error())
+		return nil
+	}
+
+	e.undo.suspended = true
+	defer e.ResetUndo()
+	e.Rows = nil
+	s := bufio.NewScanner(strings.NewReader(string(content)))
+	s.Split((&lineEndingCounter{}).Split)
+	for s.Scan() {
+		e.InsertRow(len(e.Rows), s.Text())
+	}
+	e.CX, e.CY = 0, 0
+	e.Dirty = 1
+	e.SetStatusMessage("Recovered %s from swap file", e.DisplayName())
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const tabMaxWidth = 24
+
+func (e *Editor) TabBarHeight() int {
+	if e.Config.ShowTabBar && len(e.Buffers) > 1 {
+		return 1
+	}
+	return 0
+}
+
+func (e *Editor) TextRows() int {
+	return e.WindowRows()
+}
+
+func (e *Editor) DrawTabBar(b *strings.Builder) {
+	if e.TabBarHeight() == 0 {
+		return
+	}
+
+	width := 0
+	for i, buf := range e.Buffers {
+		name := "[No Name]"
+		if buf.Filename != "" {
+			name = filepath.Base(buf.Filename)
+		}
+		if buf.Dirty > 0 {
+			name += "*"
+		}
+
+		label := fmt.Sprintf(" %d:%s ", i+1, runewidth.Truncate(name, tabMaxWidth, "..."))
+		if width+runewidth.StringWidth(label) > e.ScreenCols {
+			label = runewidth.Truncate(label, e.ScreenCols-width, "")
+		}
+		if label == "" {
+			break
+		}
+		width += runewidth.StringWidth(label)
+
+		if buf == e.Buffer {
+			b.WriteString("\x1b[7m")
+			b.WriteString(label)
+			b.WriteString("\x1b[m")
+		} else {
+			b.WriteString(label)
+		}
+	}
+
+	b.WriteString("\x1b[K\r\n")
+}
@@ -0,0 +1,210 @@
+package main
+
+import "unicode"
+
+type editKind int
+
+const (
+	editInsertRunes editKind = iota
+	editDeleteRunes
+	editInsertRow
+	editDeleteRow
+)
+
+type editOp struct {
+	kind  editKind
+	row   int
+	at    int
+	chars []rune
+}
+
+type undoGroup struct {
+	ops              []editOp
+	cx, cy           int
+	dirty            int
+	afterCX, afterCY int
+	afterDirty       int
+	size             int
+}
+
+type undoHistory struct {
+	undo      []*undoGroup
+	redo      []*undoGroup
+	sealed    bool
+	typing    bool
+	suspended bool
+	step      *undoGroup
+	cx, cy    int
+	dirty     int
+	size      int
+}
+
+const undoOpOverhead = 48
+
+func (op editOp) size() int {
+	return undoOpOverhead + 4*len(op.chars)
+}
+
+func isTypingKey(k key) bool {
+	return k < keyArrowLeft && !unicode.IsControl(rune(k)) && !unicode.IsSpace(rune(k))
+}
+
+func (e *Editor) BeginUndoStep(k key) {
+	h := &e.undo
+	typing := isTypingKey(k)
+	if !typing || !h.typing {
+		h.sealed = true
+	}
+	h.typing = typing
+	h.step = nil
+	h.cx, h.cy, h.dirty = e.CX, e.CY, e.Dirty
+}
+
+func (e *Editor) EndUndoStep() {
+	h := &e.undo
+	if h.step == nil {
+		return
+	}
+	h.step.afterCX, h.step.afterCY, h.step.afterDirty = e.CX, e.CY, e.Dirty
+	h.step = nil
+}
+
+func (e *Editor) ResetUndo() {
+	e.undo = undoHistory{sealed: true}
+}
+
+func (e *Editor) recordEdit(op editOp) {
+	h := &e.undo
+	if h.suspended {
+		return
+	}
+
+	if h.sealed || len(h.undo) == 0 {
+		h.undo = append(h.undo, &undoGroup{cx: h.cx, cy: h.cy, dirty: h.dirty})
+		h.sealed = false
+	}
+
+	g := h.undo[len(h.undo)-1]
+	g.ops = append(g.ops, op)
+	g.size += op.size()
+	h.size += op.size()
+	h.step = g
+	h.redo = nil
+	e.trimUndo()
+}
+
+func (e *Editor) trimUndo() {
+	h := &e.undo
+	levels := e.Config.MaxUndoLevels
+	limit := e.Config.MaxUndoMemory * 1024 * 1024
+	for len(h.undo) > 1 && ((levels > 0 && len(h.undo) > levels) || (limit > 0 && h.size > limit)) {
+		h.size -= h.undo[0].size
+		h.undo[0] = nil
+		h.undo = h.undo[1:]
+	}
+}
+
+func (e *Editor) ShowUndoHistory() {
+	h := &e.undo
+	redo := 0
+	for _, g := range h.redo {
+		redo += g.size
+	}
+	e.SetStatusMessage("Undo history: %d steps, %d redo steps, about %d KB", len(h.undo), len(h.redo), (h.size+redo+1023)/1024)
+}
+
+func (e *Editor) MarkUndoSaved() {
+	h := &e.undo
+	for _, g := range h.undo {
+		g.dirty, g.afterDirty = 1, 1
+	}
+	for _, g := range h.redo {
+		g.dirty, g.afterDirty = 1, 1
+	}
+	if len(h.undo) > 0 {
+		h.undo[len(h.undo)-1].afterDirty = 0
+	}
+	if len(h.redo) > 0 {
+		h.redo[len(h.redo)-1].dirty = 0
+	}
+	h.sealed = true
+}
+
+func (e *Editor) Undo() bool {
+	h := &e.undo
+	if len(h.undo) == 0 {
+		return false
+	}
+	if !e.Writable() {
+		return true
+	}
+
+	g := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.size -= g.size
+
+	h.suspended = true
+	for i := len(g.ops) - 1; i >= 0; i-- {
+		e.applyEdit(g.ops[i], true)
+	}
+	h.suspended = false
+
+	e.CX, e.CY, e.Dirty = g.cx, g.cy, g.dirty
+	h.redo = append(h.redo, g)
+	h.sealed = true
+	h.typing = false
+	return true
+}
+
+func (e *Editor) Redo() bool {
+	h := &e.undo
+	if len(h.redo) == 0 {
+		return false
+	}
+	if !e.Writable() {
+		return true
+	}
+
+	g := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+
+	h.suspended = true
+	for _, op := range g.ops {
+		e.applyEdit(op, false)
+	}
+	h.suspended = false
+
+	e.CX, e.CY, e.Dirty = g.afterCX, g.afterCY, g.afterDirty
+	h.undo = append(h.undo, g)
+	h.size += g.size
+	h.sealed = true
+	h.typing = false
+	return true
+}
+
+func (e *Editor) applyEdit(op editOp, reverse bool) {
+	kind := op.kind
+	if reverse {
+		switch kind {
+		case editInsertRunes:
+			kind = editDeleteRunes
+		case editDeleteRunes:
+			kind = editInsertRunes
+		case editInsertRow:
+			kind = editDeleteRow
+		case editDeleteRow:
+			kind = editInsertRow
+		}
+	}
+
+	switch kind {
+	case editInsertRunes:
+		e.InsertRunes(op.row, op.at, op.chars)
+	case editDeleteRunes:
+		e.DeleteRunes(op.row, op.at, len(op.chars))
+	case editInsertRow:
+		e.InsertRow(op.row, string(op.chars))
+	case editDeleteRow:
+		e.DeleteRow(op.row)
+	}
+}
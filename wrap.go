@@ -0,0 +1,139 @@
+package main
+
+import "github.com/mattn/go-runewidth"
+
+func (e *Editor) WrapSegments(row *Row) []int {
+	cols := e.TextCols()
+	runes := []rune(row.render)
+	segs := []int{0}
+	start, width, lastSpace := 0, 0, -1
+	for i := 0; i < len(runes); i++ {
+		w := runewidth.RuneWidth(runes[i])
+		if width+w > cols && i > start {
+			next := i
+			if lastSpace > start {
+				next = lastSpace + 1
+			}
+			segs = append(segs, next)
+			start, lastSpace = next, -1
+			width = runewidth.StringWidth(string(runes[start:i]))
+		}
+		if runes[i] == ' ' {
+			lastSpace = i
+		}
+		width += w
+	}
+	return segs
+}
+
+func (e *Editor) wrapPosition(y, cx int) (seg, col int, segs []int) {
+	if y >= len(e.Rows) {
+		return 0, 0, []int{0}
+	}
+
+	row := e.Rows[y]
+	segs = e.WrapSegments(row)
+	idx := e.RowCxToRenderIdx(row, cx)
+	for seg+1 < len(segs) && segs[seg+1] <= idx {
+		seg++
+	}
+	return seg, runewidth.StringWidth(UTF8Slice(row.render, segs[seg], idx)), segs
+}
+
+func (e *Editor) screenRowsBetween(from, to int) int {
+	n := 0
+	for y := from; y < to && y < len(e.Rows); y++ {
+		switch {
+		case e.IsHidden(y):
+		case e.Config.WordWrap:
+			n += len(e.WrapSegments(e.Rows[y]))
+		default:
+			n++
+		}
+	}
+	if to > len(e.Rows) {
+		n += to - len(e.Rows)
+	}
+	return n
+}
+
+func (e *Editor) ScrollWrapped() {
+	e.ColOffset = 0
+	seg, _, _ := e.wrapPosition(e.CY, e.CX)
+	e.scrollVisible(seg)
+}
+
+func (e *Editor) scrollVisible(seg int) {
+	if e.CY < e.RowOffset {
+		e.RowOffset = e.CY
+	}
+
+	top, n := e.CY, seg+1
+	for top > e.RowOffset {
+		h := e.screenRowsBetween(top-1, top)
+		if n+h > e.TextRows() {
+			break
+		}
+		top, n = top-1, n+h
+	}
+	e.RowOffset = top
+
+	for e.RowOffset < e.CY && e.IsHidden(e.RowOffset) {
+		e.RowOffset++
+	}
+}
+
+func (e *Editor) WrappedCursor() (int, int) {
+	seg, col, _ := e.wrapPosition(e.CY, e.CX)
+	return e.screenRowsBetween(e.RowOffset, e.CY) + seg, col
+}
+
+func (e *Editor) renderIdxToCx(row *Row, target int) int {
+	for cx := range row.chars {
+		if e.RowCxToRenderIdx(row, cx+1) > target {
+			return cx
+		}
+	}
+	return len(row.chars)
+}
+
+func (e *Editor) MoveVisual(dir int) {
+	seg, col, segs := e.wrapPosition(e.CY, e.CX)
+	seg += dir
+	if seg < 0 {
+		if e.CY == 0 {
+			return
+		}
+		e.CY--
+		_, _, segs = e.wrapPosition(e.CY, 0)
+		seg = len(segs) - 1
+	} else if seg >= len(segs) {
+		if e.CY >= len(e.Rows) {
+			return
+		}
+		e.CY++
+		_, _, segs = e.wrapPosition(e.CY, 0)
+		seg = 0
+	}
+
+	if e.CY >= len(e.Rows) {
+		e.CX = 0
+		return
+	}
+
+	row := e.Rows[e.CY]
+	runes := []rune(row.render)
+	end := len(runes)
+	if seg+1 < len(segs) {
+		end = segs[seg+1] - 1
+	}
+
+	idx := segs[seg]
+	for width := 0; idx < end; idx++ {
+		width += runewidth.RuneWidth(runes[idx])
+		if width > col {
+			break
+		}
+	}
+	e.CX = e.renderIdxToCx(row, idx)
+}
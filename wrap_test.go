@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScrollWrappedKeepsCursorOnScreen(t *testing.T) {
+	lines := make([]string, 40)
+	for i := range lines {
+		lines[i] = strings.TrimSpace(strings.Repeat("word ", 40))
+	}
+	e := newTestEditor(lines...)
+	e.Config.WordWrap = true
+	if n := len(e.WrapSegments(e.Rows[0])); n != 3 {
+		t.Fatalf("line wraps into %d segments, want 3", n)
+	}
+
+	e.CY = 30
+	e.Scroll()
+	if want := 30 - (e.TextRows()-1)/3; e.RowOffset != want {
+		t.Fatalf("RowOffset = %d, want %d", e.RowOffset, want)
+	}
+	if y, _ := e.WrappedCursor(); y >= e.TextRows() {
+		t.Fatalf("cursor drawn at screen row %d of %d", y, e.TextRows())
+	}
+
+	e.CY = 28
+	e.Scroll()
+	if want := 30 - (e.TextRows()-1)/3; e.RowOffset != want {
+		t.Fatalf("RowOffset moved to %d while the cursor was visible, want %d", e.RowOffset, want)
+	}
+}
+
+func TestScrollWrappedLongJump(t *testing.T) {
+	e := newTestEditor(numberedLines(200000)...)
+	e.Config.WordWrap = true
+	e.Scroll()
+
+	e.CY = len(e.Rows) - 1
+	e.Scroll()
+	if want := len(e.Rows) - e.TextRows(); e.RowOffset != want {
+		t.Fatalf("RowOffset = %d, want %d", e.RowOffset, want)
+	}
+}